@@ -0,0 +1,108 @@
+package beat
+
+import (
+	"fmt"
+	"time"
+)
+
+// 通过 AddAfter 添加的任务的定时时间，从不按时间运行
+type afterSchedule struct{}
+
+func (afterSchedule) Next(time.Time) time.Time {
+	return time.Time{}
+}
+
+// 在上游任务之后运行，不会因为没有运行时间而结束
+func (afterSchedule) Finite() bool {
+	return false
+}
+
+// 添加在另一个任务执行结束后运行的任务
+//
+//	afterId: 上游任务ID，该任务每次执行成功后运行一次新任务
+//	id: 任务ID，每个任务ID唯一
+//	fn: 任务执行回调
+//	userdata: 用于保存用户数据，回调时将传递该数据
+//	opts: 任务选项，可使用 WithTriggerOnFailure 使上游任务失败时也运行
+//
+// 新任务没有定时表达式，不会被 Save 保存；上游任务可以稍后添加，panic 时不会运行新任务。
+// 任务ID或上游任务ID为空时返回 ErrEmptyId；任务直接或间接在自身之后运行时返回 ErrCyclicAfter
+func (b *Beat) AddAfter(afterId string, id string, fn JobFunc, userdata any, opts ...JobOption) error {
+	if afterId == "" || id == "" {
+		return ErrEmptyId
+	}
+
+	opts = append(opts[:len(opts):len(opts)], func(j *job) {
+		j.after = afterId
+	})
+
+	return b.addSchedule("", afterSchedule{}, id, toJobFuncE(fn), userdata, opts)
+}
+
+// 判断是否有在指定任务之后运行的任务
+func (b *Beat) hasDownstream(id string) bool {
+	return b.downstream[id] > 0
+}
+
+// 添加或移除任务时更新上游任务的下游任务数量，delta 为 1 或 -1
+func (b *Beat) trackDownstream(job *job, delta int) {
+	if job.after == "" {
+		return
+	}
+
+	if n := b.downstream[job.after] + delta; n > 0 {
+		b.downstream[job.after] = n
+	} else {
+		delete(b.downstream, job.after)
+	}
+}
+
+// 检查任务的上游任务链，任务直接或间接在自身之后运行时返回 ErrCyclicAfter
+func (b *Beat) checkAfter(job *job) error {
+	// 已有任务中没有环，最多经过所有任务即可到达链的末端
+	id := job.after
+	for range len(b.jobs) + 1 {
+		if id == "" {
+			return nil
+		}
+		if id == job.Id {
+			return fmt.Errorf("%w: %s", ErrCyclicAfter, job.Id)
+		}
+
+		upstream := b.find(id)
+		if upstream == nil {
+			return nil
+		}
+		id = upstream.after
+	}
+
+	return nil
+}
+
+// 运行在指定任务之后运行的任务
+//
+// failed 表示上游任务是否执行失败，失败时只运行启用了 WithTriggerOnFailure 的任务
+func (b *Beat) runDownstream(id string, failed bool, now time.Time) {
+	for _, job := range b.jobs {
+		if job.after != id || (failed && !job.afterFailure) {
+			continue
+		}
+
+		b.log.Debug("job.action", "execute", "job.id", job.Id, "job.after", id)
+		b.executeJob(job, now)
+	}
+}
+
+// 上游任务执行结束后，通知调度协程运行其后的任务
+//
+// 在任务的协程中调用，调度器已停止时不再运行
+func (b *Beat) notifyDownstream(id string, failed bool) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if !b.running {
+		return
+	}
+
+	b.operate <- opRunAfter{id: id, failed: failed}
+}
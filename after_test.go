@@ -0,0 +1,82 @@
+package beat
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// Run upstream jobs that succeed and fail, expect downstream jobs run according to the upstream result.
+func TestAddAfter(t *testing.T) {
+	ran := make(chan string, 4)
+	record := func(ctx context.Context, userdata any) { ran <- userdata.(string) }
+
+	beat := New(WithErrorHandler(func(string, error) {}))
+	if err := beat.AddAfter("", "TestAddAfter-1", record, nil); !errors.Is(err, ErrEmptyId) {
+		t.Errorf("expected %v, got %v", ErrEmptyId, err)
+	}
+
+	beat.Add("* 1 1 * 0 0 0", "TestAddAfter-ok", nil, nil)
+	beat.AddE("* 1 1 * 0 0 0", "TestAddAfter-fail", func(ctx context.Context, userdata any) error {
+		return errors.New("job failed")
+	}, nil)
+	beat.AddAfter("TestAddAfter-ok", "TestAddAfter-1", record, "after-ok")
+	beat.AddAfter("TestAddAfter-fail", "TestAddAfter-2", record, "after-fail")
+	beat.AddAfter("TestAddAfter-fail", "TestAddAfter-3", record, "after-fail-always", WithTriggerOnFailure())
+	beat.Start()
+	defer beat.Stop()
+
+	if entry, _ := beat.Entry("TestAddAfter-1"); !entry.Next.IsZero() {
+		t.Errorf("expected downstream job has no next run time, got %v", entry.Next)
+	}
+
+	beat.RunNow("TestAddAfter-ok")
+	beat.RunNow("TestAddAfter-fail")
+
+	got := map[string]bool{}
+	for range 2 {
+		select {
+		case id := <-ran:
+			got[id] = true
+		case <-time.After(OneSecond):
+			t.Fatalf("expected downstream jobs run, got %v", got)
+		}
+	}
+	if !got["after-ok"] || !got["after-fail-always"] {
+		t.Errorf("unexpected downstream jobs %v", got)
+	}
+
+	select {
+	case id := <-ran:
+		t.Errorf("expected %s does not run after a failure", id)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+// Add jobs running after themselves directly or through a chain, expect ErrCyclicAfter and no change.
+func TestAddAfterCycle(t *testing.T) {
+	beat := New()
+	if err := beat.AddAfter("TestAddAfterCycle-1", "TestAddAfterCycle-1", nil, nil); !errors.Is(err, ErrCyclicAfter) {
+		t.Errorf("expected %v, got %v", ErrCyclicAfter, err)
+	}
+
+	beat.Add("* 1 1 * 0 0 0", "TestAddAfterCycle-a", nil, nil)
+	beat.AddAfter("TestAddAfterCycle-a", "TestAddAfterCycle-b", nil, nil)
+	beat.AddAfter("TestAddAfterCycle-b", "TestAddAfterCycle-c", nil, nil)
+	if err := beat.AddAfter("TestAddAfterCycle-c", "TestAddAfterCycle-a", nil, nil); !errors.Is(err, ErrCyclicAfter) {
+		t.Errorf("expected %v, got %v", ErrCyclicAfter, err)
+	}
+	if entry, _ := beat.Entry("TestAddAfterCycle-a"); entry.Expr != "* 1 1 * 0 0 0" {
+		t.Errorf("expected upstream job is kept, got %+v", entry)
+	}
+	if !beat.hasDownstream("TestAddAfterCycle-a") || !beat.hasDownstream("TestAddAfterCycle-b") {
+		t.Error("expected downstream jobs are tracked")
+	}
+
+	beat.Remove("TestAddAfterCycle-b")
+	beat.RemoveByPrefix("TestAddAfterCycle-c")
+	if beat.hasDownstream("TestAddAfterCycle-a") || beat.hasDownstream("TestAddAfterCycle-b") {
+		t.Error("expected removed downstream jobs are not tracked")
+	}
+}
@@ -0,0 +1,188 @@
+package beat
+
+import "fmt"
+
+// 批量操作时的任务描述，字段与 Add 的参数相同
+type JobSpec struct {
+	Expr     string      // 定时表达式
+	Id       string      // 任务ID
+	Func     JobFunc     // 任务执行回调
+	Userdata any         // 用户数据
+	Options  []JobOption // 任务选项
+}
+
+// 解析所有任务描述并创建任务，任何一个无效时返回错误
+func (b *Beat) newJobs(specs []JobSpec) ([]*job, error) {
+	jobs := make([]*job, 0, len(specs))
+
+	for _, spec := range specs {
+		job, err := b.parseJob(spec.Expr, spec.Id, toJobFuncE(spec.Func), spec.Userdata, spec.Options)
+		if err != nil {
+			return nil, fmt.Errorf("job %s: %w", spec.Id, err)
+		}
+
+		jobs = append(jobs, job)
+	}
+
+	return jobs, nil
+}
+
+// 检查批次中所有任务的最小间隔，任何一个过于频繁时返回 ErrTooFrequent。调用前需持有互斥锁
+func (b *Beat) checkIntervals(jobs []*job) error {
+	for _, job := range jobs {
+		if err := b.checkInterval(job.Schedule); err != nil {
+			return fmt.Errorf("job %s: %w", job.Id, err)
+		}
+	}
+
+	return nil
+}
+
+// 检查批次中的任务ID，启用了 rejectDup 时任务ID在批次中重复或满足 exists 则返回 ErrJobExist
+func (b *Beat) checkJobs(jobs []*job, exists func(id string) bool) error {
+	if !b.rejectDup {
+		return nil
+	}
+
+	seen := make(map[string]bool, len(jobs))
+	for _, job := range jobs {
+		if seen[job.Id] || exists(job.Id) {
+			return fmt.Errorf("%w: %s", ErrJobExist, job.Id)
+		}
+		seen[job.Id] = true
+	}
+
+	return nil
+}
+
+// 添加一批任务
+//
+// 启用了 rejectDup 时，任何一个任务ID已存在或在批次中重复则返回 ErrJobExist，且不添加任何任务
+func (b *Beat) addJobs(jobs []*job) error {
+	err := b.checkJobs(jobs, func(id string) bool { return b.find(id) != nil })
+	if err != nil {
+		return err
+	}
+	if err := b.checkWeights(jobs); err != nil {
+		return err
+	}
+
+	for _, job := range jobs {
+		if err := b.addJob(job); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// 检查批次中所有任务的权重，任何一个超过最大协程数量时返回 ErrInvalidWeight
+func (b *Beat) checkWeights(jobs []*job) error {
+	for _, job := range jobs {
+		if err := b.checkWeight(job); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// 移除全部任务并添加一批任务
+//
+// 启用了 rejectDup 时，任务ID在批次中重复则返回 ErrJobExist，且不做任何修改
+func (b *Beat) replaceAllJob(jobs []*job) error {
+	err := b.checkJobs(jobs, func(string) bool { return false })
+	if err != nil {
+		return err
+	}
+	if err := b.checkWeights(jobs); err != nil {
+		return err
+	}
+
+	b.removeAllJob()
+
+	return b.addJobs(jobs)
+}
+
+// 批量添加任务
+//
+// 先解析所有定时表达式，任何一个无效时返回错误且不添加任何任务；
+// 运行中时所有任务在一次调度请求中添加，不会只添加部分任务
+func (b *Beat) AddBatch(specs []JobSpec) error {
+	jobs, err := b.newJobs(specs)
+	if err != nil {
+		return err
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if err := b.checkIntervals(jobs); err != nil {
+		return err
+	}
+
+	if !b.running {
+		return b.addJobs(jobs)
+	}
+
+	reply := make(chan error)
+	b.operate <- opAddBatch{jobs: jobs, reply: reply}
+
+	return <-reply
+}
+
+// 移除一批任务，返回移除的任务数量
+func (b *Beat) removeJobs(ids []string) int {
+	set := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		set[id] = true
+	}
+
+	return b.removeJobIf(func(job *job) bool {
+		return set[job.Id]
+	})
+}
+
+// 批量移除任务，不存在的任务ID将被忽略
+//
+// 运行中时所有任务在一次调度请求中移除，返回实际移除的任务数量
+func (b *Beat) RemoveBatch(ids []string) int {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if !b.running {
+		return b.removeJobs(ids)
+	}
+
+	reply := make(chan int)
+	b.operate <- opRemoveBatch{ids: ids, reply: reply}
+
+	return <-reply
+}
+
+// 使用一批任务替换全部任务
+//
+// 先解析所有定时表达式，任何一个无效时返回错误且不做任何修改；
+// 运行中时移除和添加在一次调度请求中完成，不会出现没有任务的间隙
+func (b *Beat) ReplaceAll(specs []JobSpec) error {
+	jobs, err := b.newJobs(specs)
+	if err != nil {
+		return err
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if err := b.checkIntervals(jobs); err != nil {
+		return err
+	}
+
+	if !b.running {
+		return b.replaceAllJob(jobs)
+	}
+
+	reply := make(chan error)
+	b.operate <- opReplaceAll{jobs: jobs, reply: reply}
+
+	return <-reply
+}
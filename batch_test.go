@@ -0,0 +1,145 @@
+package beat
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// Add batches with an invalid expression or a duplicate id, expect nothing is added;
+// add a valid batch while running, expect all jobs run.
+func TestAddBatch(t *testing.T) {
+	ran := make(chan string, 2)
+	fn := func(ctx context.Context, userdata any) {
+		select {
+		case ran <- userdata.(string):
+		default:
+		}
+	}
+
+	beat := New(WithRejectDuplicates())
+	beat.Add("* * * * * * *", "TestAddBatch-0", nil, nil)
+
+	err := beat.AddBatch([]JobSpec{
+		{Expr: "* * * * * * *", Id: "TestAddBatch-1"},
+		{Expr: "invalid", Id: "TestAddBatch-2"},
+	})
+	if !errors.Is(err, ErrInvalidExp) {
+		t.Errorf("expected ErrInvalidExp, got %v", err)
+	}
+
+	err = beat.AddBatch([]JobSpec{
+		{Expr: "* * * * * * *", Id: "TestAddBatch-1"},
+		{Expr: "* * * * * * *", Id: ""},
+	})
+	if !errors.Is(err, ErrEmptyId) {
+		t.Errorf("expected ErrEmptyId, got %v", err)
+	}
+
+	err = beat.AddBatch([]JobSpec{
+		{Expr: "* * * * * * *", Id: "TestAddBatch-1"},
+		{Expr: "* * * * * * *", Id: "TestAddBatch-0"},
+	})
+	if !errors.Is(err, ErrJobExist) {
+		t.Errorf("expected ErrJobExist, got %v", err)
+	}
+
+	if n := beat.Count(); n != 1 {
+		t.Fatalf("expected failed batches add nothing, got %d jobs", n)
+	}
+
+	beat.Start()
+	defer beat.Stop()
+
+	err = beat.AddBatch([]JobSpec{
+		{Expr: "* * * * * * *", Id: "TestAddBatch-1", Func: fn, Userdata: "1"},
+		{Expr: "* * * * * * *", Id: "TestAddBatch-2", Func: fn, Userdata: "2", Options: []JobOption{WithSkipIfStillRunning()}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := beat.Count(); n != 3 {
+		t.Errorf("expected 3 jobs, got %d", n)
+	}
+
+	for range 2 {
+		select {
+		case <-time.After(OneSecond):
+			t.Fatal("expected batch jobs run")
+		case <-ran:
+		}
+	}
+}
+
+// Remove batches of jobs before and while running, expect unknown ids are ignored.
+func TestRemoveBatch(t *testing.T) {
+	beat := New()
+	for _, id := range []string{"TestRemoveBatch-1", "TestRemoveBatch-2", "TestRemoveBatch-3", "TestRemoveBatch-4"} {
+		beat.Add("* * * * * * *", id, nil, nil)
+	}
+
+	if n := beat.RemoveBatch([]string{"TestRemoveBatch-1", "unknown"}); n != 1 {
+		t.Errorf("expected 1 job removed, got %d", n)
+	}
+
+	beat.Start()
+	defer beat.Stop()
+
+	if n := beat.RemoveBatch([]string{"TestRemoveBatch-2", "TestRemoveBatch-3", "TestRemoveBatch-2"}); n != 2 {
+		t.Errorf("expected 2 jobs removed, got %d", n)
+	}
+	if beat.Count() != 1 || !beat.Contains("TestRemoveBatch-4") {
+		t.Errorf("expected only TestRemoveBatch-4 remains, got %v", beat.Entries())
+	}
+}
+
+// Replace all jobs with invalid and valid batches before and while running.
+func TestReplaceAll(t *testing.T) {
+	beat := New(WithRejectDuplicates())
+	beat.Add("* * * * * * *", "TestReplaceAll-1", nil, nil)
+	beat.Add("* * * * * * *", "TestReplaceAll-2", nil, nil)
+
+	err := beat.ReplaceAll([]JobSpec{
+		{Expr: "* * * * * * *", Id: "TestReplaceAll-3"},
+		{Expr: "invalid", Id: "TestReplaceAll-4"},
+	})
+	if !errors.Is(err, ErrInvalidExp) {
+		t.Errorf("expected ErrInvalidExp, got %v", err)
+	}
+
+	err = beat.ReplaceAll([]JobSpec{
+		{Expr: "* * * * * * *", Id: "TestReplaceAll-3"},
+		{Expr: "* * * * * * *", Id: "TestReplaceAll-3"},
+	})
+	if !errors.Is(err, ErrJobExist) {
+		t.Errorf("expected ErrJobExist, got %v", err)
+	}
+
+	if beat.Count() != 2 || !beat.Contains("TestReplaceAll-1") {
+		t.Fatalf("expected failed replacements change nothing, got %v", beat.Entries())
+	}
+
+	// Existing ids may be kept in the new set.
+	err = beat.ReplaceAll([]JobSpec{
+		{Expr: "@hourly", Id: "TestReplaceAll-2"},
+		{Expr: "* * * * * * *", Id: "TestReplaceAll-3"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry, _ := beat.Entry("TestReplaceAll-2"); beat.Count() != 2 || beat.Contains("TestReplaceAll-1") || entry.Expr != "@hourly" {
+		t.Errorf("unexpected jobs after replacement: %v", beat.Entries())
+	}
+
+	beat.Start()
+	defer beat.Stop()
+
+	err = beat.ReplaceAll([]JobSpec{{Expr: "* * * * * * *", Id: "TestReplaceAll-4"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if beat.Count() != 1 || !beat.Contains("TestReplaceAll-4") {
+		t.Errorf("expected only TestReplaceAll-4, got %v", beat.Entries())
+	}
+}
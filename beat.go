@@ -1,42 +1,129 @@
 package beat
 
 import (
+	"container/heap"
 	"context"
+	"fmt"
+	"math/rand/v2"
 	"regexp"
 	"runtime"
+	"slices"
 	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"golang.org/x/sync/semaphore"
+	"golang.org/x/time/rate"
 )
 
 type JobFunc func(ctx context.Context, userdata any)
 
+// 返回错误的任务，错误将传递给错误处理函数
+type JobFuncE func(ctx context.Context, userdata any) error
+
+// 将 JobFunc 转换为总是返回 nil 的 JobFuncE，fn 为 nil 时返回 nil
+func toJobFuncE(fn JobFunc) JobFuncE {
+	if fn == nil {
+		return nil
+	}
+
+	return func(ctx context.Context, userdata any) error {
+		fn(ctx, userdata)
+		return nil
+	}
+}
+
 type job struct {
-	Id       string  // 任务ID
-	Func     JobFunc // 定时执行的任务
-	Userdata any     // 用户数据
+	Id       string   // 任务ID
+	Func     JobFuncE // 定时执行的任务
+	Userdata any      // 用户数据
 
+	Expr     string    // 定时表达式
 	Schedule Schedule  // 定时时间
 	Next     time.Time // 下一次运行的时间
 	Prev     time.Time // 前一次运行的时间
+
+	index    int    // 在堆中的位置
+	seq      uint64 // 添加的序号，运行时间和优先级相同时先添加的任务先执行
+	priority int    // 优先级，运行时间相同时优先级高的任务先执行
+	weight   int64  // 执行时占用的协程数量，不大于 0 时为 1
+	paused   bool   // 是否暂停
+	once     bool   // 是否在运行结束后自动移除，用于 AddOnce
+
+	timeout        time.Duration                   // 每次执行的超时时间，0 表示不限制
+	retries        int                             // 返回错误时的最大重试次数
+	backoff        func(int) time.Duration         // 第 n 次重试前的等待时间
+	jitter         time.Duration                   // 每次运行时间的最大随机延迟，0 表示使用 Beat 的设置
+	wrappers       []JobWrapper                    // 应用于该任务的 JobWrapper
+	tags           []string                        // 任务标签，用于对任务分组
+	blackout       func(time.Time) bool            // 禁止运行的时间段，返回 true 的时间不运行
+	guard          func(context.Context, any) bool // 到达定时时的守卫，返回 false 时跳过本次执行
+	after          string                          // 上游任务ID，上游任务执行结束后运行
+	afterFailure   bool                            // 上游任务执行失败时是否也运行
+	skipIfRunning  bool                            // 上一次执行未结束时是否跳过本次执行
+	delayIfRunning bool                            // 上一次执行未结束时是否等待其结束后再执行
+	running        atomic.Bool                     // 是否正在执行
+	runLock        sync.Mutex                      // 用于串行执行
+	stats          jobStats                        // 执行统计
 }
 
-type Beat struct {
-	jobs          []*job              // 任务集合
-	jobWaiter     sync.WaitGroup      // 任务完成等待
-	withRecovery  bool                // 是否启用recover
-	lock          sync.Mutex          // 互斥锁
-	maxGoroutines int                 // 最大协程数量
-	sem           *semaphore.Weighted //
-	running       bool                // 是否运行
-	parser        ScheduleParser      // 解析器
-	location      *time.Location      // 时区
-	ctx           context.Context     // 上下文
-	log           Logger              // log
+// 任务快照，用于查询任务状态
+type Entry struct {
+	Id       string    // 任务ID
+	Expr     string    // 定时表达式
+	Schedule Schedule  // 定时时间
+	Next     time.Time // 下一次运行的时间
+	Prev     time.Time // 前一次运行的时间
+	Tags     []string  // 任务标签
+	Paused   bool      // 是否暂停，暂停的任务没有下一次运行的时间
+	Stats    JobStats  // 获取快照时的执行统计
+}
 
-	operate chan any
+type Beat struct {
+	jobs          jobHeap                     // 任务集合
+	downstream    map[string]int              // 上游任务ID到通过 AddAfter 添加的下游任务数量的映射
+	jobWaiter     sync.WaitGroup              // 任务完成等待
+	withRecovery  bool                        // 是否启用recover，默认启用
+	errorHandler  func(string, error)         // 任务错误处理函数
+	panicHandler  func(string, any, []byte)   // 任务 panic 处理函数
+	stackSize     int                         // panic 时记录的调用栈的最大字节数，0 表示不限制
+	chain         []JobWrapper                // 应用于所有任务的 JobWrapper
+	beforeJob     func(string)                // 任务执行前的回调
+	afterJob      func(string, time.Duration) // 任务执行后的回调
+	rejectDup     bool                        // 是否拒绝重复的任务ID
+	jitter        time.Duration               // 每次运行时间的最大随机延迟
+	catchUp       int                         // 开始运行时补执行错过的运行的最大次数，0 表示不补执行
+	tryTimeout    time.Duration               // TryAdd、TryRemove 等待调度器的最长时间
+	seq           uint64                      // 最近添加的任务的序号
+	minInterval   time.Duration               // 定时时间的最小间隔，0 表示不限制
+	autoRemove    bool                        // 是否自动移除运行结束的任务
+	autoPrune     bool                        // 是否在重新计算运行时间后移除运行结束的任务
+	lock          sync.Mutex                  // 互斥锁
+	maxGoroutines int                         // 最大协程数量
+	sem           *semaphore.Weighted         //
+	limiter       *rate.Limiter               // 任务开始执行的速率限制，nil 表示不限制
+	running       bool                        // 是否运行
+	stopping      atomic.Bool                 // 是否正在停止，停止时不再执行新的任务
+	parser        ScheduleParser              // 解析器
+	location      *time.Location              // 时区
+	clock         Clock                       // 时钟
+	ctx           context.Context             // 上下文
+	jobCtx        context.Context             // 传递给任务的上下文，停止运行时取消
+	cancel        context.CancelFunc          // 取消 jobCtx
+	log           Logger                      // log
+
+	events        chan Event // 事件通道，订阅后才创建
+	eventBuffer   int        // 事件通道的缓冲大小
+	eventBlocking bool       // 事件通道已满时是否阻塞
+	eventLock     sync.Mutex // 事件通道的互斥锁
+
+	metrics    metrics    // 执行计数
+	runCancels runCancels // 正在执行的任务的取消函数
+
+	operate       chan any // 请求通道，由调度协程按顺序处理
+	operateBuffer int      // 请求通道的缓冲大小
 }
 
 type ScheduleParser interface {
@@ -44,65 +131,211 @@ type ScheduleParser interface {
 }
 
 type Schedule interface {
-	// 根据给定时间，返回下一个可用的时间
+	// 根据给定时间，返回下一个可用的时间；返回零值时间表示不再运行
 	Next(time.Time) time.Time
 }
 
-// 排序需要用到的接口
-type jobByTime []*job
+// 可选实现的接口，用于说明定时时间是否有限
+//
+// 未实现时，Next 返回零值时间即视为运行结束；Finite 返回 false 的定时时间不会被 WithAutoRemoveFinished 移除
+type FiniteSchedule interface {
+	Schedule
+	// 返回是否只运行有限次
+	Finite() bool
+}
+
+// 需要按实际运行次数计数的定时时间实现的接口，调度器每次到达运行时间时调用 fired
+type firedSchedule interface {
+	fired(scheduled time.Time)
+}
+
+// 通知任务的定时时间到达了一次运行时间
+func notifyFired(job *job, scheduled time.Time) {
+	if sched, ok := job.Schedule.(firedSchedule); ok {
+		sched.fired(scheduled)
+	}
+}
+
+// 判断任务是否已经运行结束，暂停的任务和 Finite 返回 false 的任务不视为结束
+func isFinished(job *job) bool {
+	if job.paused || !job.Next.IsZero() {
+		return false
+	}
+
+	if sched, ok := job.Schedule.(FiniteSchedule); ok {
+		return sched.Finite()
+	}
+
+	return true
+}
+
+// 按下一次运行时间排列的最小堆，零值时间排在最后
+type jobHeap []*job
 
-func (s jobByTime) Len() int {
-	return len(s)
+func (h jobHeap) Len() int {
+	return len(h)
 }
 
-func (s jobByTime) Swap(i, j int) {
-	s[i], s[j] = s[j], s[i]
+func (h jobHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
 }
 
-func (s jobByTime) Less(i, j int) bool {
-	if s[i].Next.IsZero() {
+func (h jobHeap) Less(i, j int) bool {
+	if h[i].Next.IsZero() {
 		return false
 	}
-	if s[j].Next.IsZero() {
+	if h[j].Next.IsZero() {
 		return true
 	}
 
-	return s[i].Next.Before(s[j].Next)
+	if !h[i].Next.Equal(h[j].Next) {
+		return h[i].Next.Before(h[j].Next)
+	}
+	if h[i].priority != h[j].priority {
+		return h[i].priority > h[j].priority
+	}
+
+	return h[i].seq < h[j].seq
+}
+
+func (h *jobHeap) Push(x any) {
+	job := x.(*job)
+	job.index = len(*h)
+	*h = append(*h, job)
+}
+
+func (h *jobHeap) Pop() any {
+	old := *h
+	n := len(old)
+	job := old[n-1]
+	old[n-1] = nil
+	job.index = -1
+	*h = old[:n-1]
+
+	return job
 }
 
 type (
-	opAdd             *job
-	opRemove          string
-	opRemoveAll       struct{}
-	opRemoveByPattern *regexp.Regexp
-	opStop            struct{}
+	opRemove           string
+	opRemoveAll        struct{}
+	opRemoveByPattern  *regexp.Regexp
+	opRemoveByPrefix   string
+	opRemoveByTag      string
+	opSnapshot         chan []Entry
+	opLastDurations    chan map[string]time.Duration
+	opMaxGoroutines    chan int
+	opPauseAll         struct{}
+	opResumeAll        struct{}
+	opSetMaxGoroutines int
+	opStop             chan struct{}
+	opDrain            chan struct{}
+	opBarrier          chan struct{}
+
+	opAdd struct {
+		job   *job
+		reply chan error
+		claim *atomic.Bool // TryAdd 使用，调度协程与超时的调用方先置位者生效，nil 表示总是执行
+	}
+	opAddBatch struct {
+		jobs  []*job
+		reply chan error
+	}
+	opRemoveBatch struct {
+		ids   []string
+		reply chan int
+	}
+	opReplaceAll struct {
+		jobs  []*job
+		reply chan error
+	}
+	opEntry struct {
+		id    string
+		reply chan *Entry
+	}
+	opUpcoming struct {
+		n     int
+		reply chan []Entry
+	}
+	opEntriesByPattern struct {
+		pattern *regexp.Regexp
+		reply   chan []Entry
+	}
+	opEntriesByTag struct {
+		tag   string
+		reply chan []Entry
+	}
+	opStats struct {
+		id    string
+		reply chan *JobStats
+	}
+	opRunNow struct {
+		id    string
+		reply chan error
+	}
+	opSetUserdata struct {
+		id       string
+		userdata any
+		reply    chan error
+	}
+	opPause struct {
+		id    string
+		reply chan error
+	}
+	opResume struct {
+		id    string
+		reply chan error
+	}
+	opSetLocation struct {
+		location *time.Location
+		reply    chan struct{}
+	}
+	opRunAfter struct {
+		id     string
+		failed bool
+	}
+	opUpdate struct {
+		id       string
+		expr     string
+		schedule Schedule
+		reply    chan error
+	}
 )
 
-func emptyJobFunc(_ context.Context, _ any) {}
+func emptyJobFunc(_ context.Context, _ any) error { return nil }
 
 func New(opts ...option) *Beat {
 	b := &Beat{
-		jobs:     []*job{},
-		parser:   defaultParser,
-		location: time.Local,
-		ctx:      context.Background(),
-		log:      defaultLogger,
-
-		operate: make(chan any),
+		jobs:       jobHeap{},
+		downstream: map[string]int{},
+		parser:     DefaultParser,
+		location:   time.Local,
+		clock:      realClock{},
+		ctx:        context.Background(),
+		log:        defaultLogger,
+
+		withRecovery: true,
+
+		eventBuffer: defaultEventBuffer,
+		tryTimeout:  defaultTryTimeout,
 	}
 
 	for _, opt := range opts {
 		opt(b)
 	}
 
-	if b.maxGoroutines > 0 {
-		b.sem = semaphore.NewWeighted(int64(b.maxGoroutines))
-	}
+	b.operate = make(chan any, b.operateBuffer)
+
+	b.setMaxGoroutines(b.maxGoroutines)
+
+	b.jobCtx, b.cancel = context.WithCancel(b.ctx)
 
 	return b
 }
 
-func (b *Beat) run() {
+// 调度循环，done 关闭时停止运行；done 为 nil 时只能通过 Stop 停止
+func (b *Beat) run(done <-chan struct{}) {
 	b.log.Info("msg", "started")
 	defer b.log.Info("msg", "stopped")
 
@@ -110,265 +343,1564 @@ func (b *Beat) run() {
 
 	// 获取一次所有任务的下一次有效时间
 	for _, job := range b.jobs {
-		job.Next = job.Schedule.Next(now)
-		b.log.Info("job.action", "schedule", "job.id", job.Id, "job.next", job.Next.Format(time.RFC3339))
+		b.catchUpJob(job, now)
+		b.scheduleJob(job, now)
+		b.log.Debug("job.action", "schedule", "job.id", job.Id, "job.expr", job.Expr, "job.next", job.Next.Format(time.RFC3339))
+	}
+	heap.Init(&b.jobs)
+
+	if b.autoPrune {
+		b.pruneFinished()
 	}
 
+	// 复用同一个定时器，每次处理完唤醒或请求后重新设置休眠时间
+	timer := b.clock.NewTimer(b.sleepDuration(now))
+	defer timer.Stop()
+
 	for {
-		// 对任务的下一次执行时间进行排序，
-		sort.Sort(jobByTime(b.jobs))
-
-		var timer *time.Timer
-		if len(b.jobs) == 0 || b.jobs[0].Next.IsZero() {
-			// 没有任务或者时间太长，则休眠，依然可以处理添加或者停止请求
-			//
-			// 目前 parser 的最长时间为 2 年，防止休眠时间过长错过 2 年后
-			// 的任务，此处休眠时间暂定为 1 年 (8760个小时)
-			timer = time.NewTimer(8760 * time.Hour)
-		} else {
-			// 获取最近执行时间的定时
-			timer = time.NewTimer(b.jobs[0].Next.Sub(now))
-		}
+		select {
+		case <-timer.C():
+			// 唤醒时重新获取当前时间，而不是使用定时器的时间，保证与 WithNowFunc 一致
+			now = b.now()
+			b.log.Debug("job.action", "wake")
+
+			b.fireDueJobs(now)
+
+		case <-done:
+			stopTimer(timer)
+			now = b.now()
+			// Stop 需要调度循环处理停止请求，因此在协程中调用
+			done = nil
+			b.stopping.Store(true)
+			go b.Stop()
+
+		case op := <-b.operate:
+			stopTimer(timer)
+			now = b.now()
+
+			switch arg := op.(type) {
+			case opAdd:
+				// TryAdd 已超时放弃的请求不再执行
+				if arg.claim != nil && !arg.claim.CompareAndSwap(false, true) {
+					break
+				}
 
-		for {
-			select {
-			case now = <-timer.C:
-				now = now.In(b.location)
-				b.log.Debug("job.action", "wake")
+				newJob := arg.job
 
-				// 执行所有已经到定时的任务
-				for _, job := range b.jobs {
-					if job.Next.After(now) || job.Next.IsZero() {
-						break
+				b.scheduleJob(newJob, now)
+				err := b.addJob(newJob)
+				arg.reply <- err
+
+				if err == nil {
+					b.log.Info("job.action", "add", "job.id", newJob.Id, "job.expr", newJob.Expr, "job.next", newJob.Next.Format(time.RFC3339))
+					b.pruneJob(newJob)
+				}
+
+			case opAddBatch:
+				for _, job := range arg.jobs {
+					b.scheduleJob(job, now)
+				}
+				err := b.addJobs(arg.jobs)
+				arg.reply <- err
+
+				if err == nil {
+					b.log.Info("job.action", "add-batch", "job.count", len(arg.jobs))
+					for _, job := range arg.jobs {
+						b.pruneJob(job)
 					}
-					b.log.Debug("job.action", "execute", "job.id", job.Id)
-					b.executeJob(job)
+				}
 
-					job.Prev = job.Next
-					job.Next = job.Schedule.Next(now)
+			case opReplaceAll:
+				for _, job := range arg.jobs {
+					b.scheduleJob(job, now)
 				}
+				err := b.replaceAllJob(arg.jobs)
+				arg.reply <- err
 
-			case op := <-b.operate:
-				timer.Stop()
-				now = b.now()
+				if err == nil {
+					b.log.Info("job.action", "replace-all", "job.count", len(arg.jobs))
+					for _, job := range arg.jobs {
+						b.pruneJob(job)
+					}
+				}
+
+			case opRemove:
+				id := string(arg)
+
+				b.removeJob(id)
+
+				b.log.Info("job.action", "remove", "job.id", id)
+
+			case opRemoveBatch:
+				removed := b.removeJobs(arg.ids)
+				arg.reply <- removed
+
+				b.log.Info("job.action", "remove-batch", "job.count", removed)
+
+			case opRemoveAll:
+				b.removeAllJob()
+
+				b.log.Info("job.action", "remove-all")
+
+			case opRemoveByPattern:
+				pattern := (*regexp.Regexp)(arg)
+
+				b.removeJobByPattern(pattern)
+
+				b.log.Info("job.action", "remove-by-pattern", "job.pattern", pattern.String())
+
+			case opRemoveByPrefix:
+				prefix := string(arg)
+
+				b.removeJobByPrefix(prefix)
+
+				b.log.Info("job.action", "remove-by-prefix", "job.prefix", prefix)
+
+			case opRemoveByTag:
+				tag := string(arg)
+
+				b.removeJobByTag(tag)
+
+				b.log.Info("job.action", "remove-by-tag", "job.tag", tag)
+
+			case opSnapshot:
+				arg <- b.entries()
+
+			case opUpcoming:
+				arg.reply <- b.upcoming(arg.n)
+
+			case opEntriesByPattern:
+				arg.reply <- b.entriesByPattern(arg.pattern)
+
+			case opEntriesByTag:
+				arg.reply <- b.entriesByTag(arg.tag)
+
+			case opEntry:
+				arg.reply <- b.entry(arg.id)
+
+			case opStats:
+				arg.reply <- b.jobStats(arg.id)
+
+			case opLastDurations:
+				arg <- b.lastDurations()
 
-				switch arg := op.(type) {
-				case opAdd:
-					newJob := (*job)(arg)
+			case opRunNow:
+				err := b.runJobNow(arg.id)
+				arg.reply <- err
 
-					newJob.Next = newJob.Schedule.Next(now)
-					b.addJob(newJob)
+				if err == nil {
+					b.log.Info("job.action", "run-now", "job.id", arg.id)
+				}
+
+			case opRunAfter:
+				b.runDownstream(arg.id, arg.failed, now)
+
+			case opUpdate:
+				job, err := b.updateJob(arg.id, arg.expr, arg.schedule)
+				if err == nil {
+					b.scheduleJob(job, now)
+					heap.Fix(&b.jobs, job.index)
+				}
+				arg.reply <- err
+
+				if err == nil {
+					b.log.Info("job.action", "update", "job.id", job.Id, "job.expr", job.Expr, "job.next", job.Next.Format(time.RFC3339))
+					b.pruneJob(job)
+				}
+
+			case opSetUserdata:
+				err := b.setJobUserdata(arg.id, arg.userdata)
+				arg.reply <- err
+
+				if err == nil {
+					b.log.Info("job.action", "set-userdata", "job.id", arg.id)
+				}
+
+			case opPause:
+				err := b.pauseJob(arg.id)
+				arg.reply <- err
+
+				if err == nil {
+					b.log.Info("job.action", "pause", "job.id", arg.id)
+				}
 
-					b.log.Info("job.action", "add", "job.id", newJob.Id, "job.next", newJob.Next.Format(time.RFC3339))
+			case opResume:
+				err := b.resumeJob(arg.id, now)
+				arg.reply <- err
 
-				case opRemove:
-					id := string(arg)
+				if err == nil {
+					b.log.Info("job.action", "resume", "job.id", arg.id)
+					b.pruneJob(b.find(arg.id))
+				}
+
+			case opSetLocation:
+				b.location = arg.location
+				now = b.now()
+				b.rescheduleAllJob(now)
+				arg.reply <- struct{}{}
 
-					b.removeJob(id)
+				b.log.Info("job.action", "set-location", "location", b.location.String())
+				if b.autoPrune {
+					b.pruneFinished()
+				}
 
-					b.log.Info("job.action", "remove", "job.id", id)
+			case opSetMaxGoroutines:
+				b.setMaxGoroutines(int(arg))
 
-				case opRemoveAll:
-					b.removeAllJob()
+				b.log.Info("job.action", "set-max-goroutines", "max", int(arg))
 
-					b.log.Info("job.action", "remove-all")
+			case opMaxGoroutines:
+				arg <- b.maxGoroutines
 
-				case opRemoveByPattern:
-					pattern := (*regexp.Regexp)(arg)
+			case opPauseAll:
+				b.pauseAllJob()
 
-					b.removeJobByPattern(pattern)
+				b.log.Info("job.action", "pause-all")
 
-					b.log.Info("job.action", "remove-by-pattern", "job.pattern", pattern.String())
+			case opResumeAll:
+				b.resumeAllJob(now)
 
-				case opStop:
-					return
+				b.log.Info("job.action", "resume-all")
+				if b.autoPrune {
+					b.pruneFinished()
 				}
+
+			case opBarrier:
+				close(arg)
+
+			case opStop:
+				close(arg)
+				return
+
+			case opDrain:
+				b.fireDueJobs(now)
+				close(arg)
+
+				b.log.Info("job.action", "drain")
+				return
 			}
+		}
 
-			break
+		timer.Reset(b.sleepDuration(now))
+	}
+}
+
+// 计算距离最近一次运行的休眠时间
+func (b *Beat) sleepDuration(now time.Time) time.Duration {
+	if len(b.jobs) == 0 || b.jobs[0].Next.IsZero() {
+		// 没有任务或者没有下一次运行时间，则休眠，依然可以处理添加或者停止请求
+		//
+		// 唤醒后将重新计算休眠时间，此处休眠时间暂定为 1 年 (8760个小时)
+		return 8760 * time.Hour
+	}
+
+	// 获取最近执行时间的定时
+	return b.jobs[0].Next.Sub(now)
+}
+
+// 停止定时器，并清空已经触发但未读取的时间，保证之后 Reset 时通道中没有旧的时间
+func stopTimer(timer Timer) {
+	if !timer.Stop() {
+		select {
+		case <-timer.C():
+		default:
 		}
 	}
 }
 
 // 返回 b.location 的当前时间
 func (b *Beat) now() time.Time {
-	return time.Now().In(b.location)
+	return b.clock.Now().In(b.location)
+}
+
+// 到达定时时执行任务，设置了守卫且守卫返回 false 时跳过本次执行
+func (b *Beat) fireJob(job *job, scheduled time.Time) {
+	if job.guard != nil && !job.guard(b.jobCtx, job.Userdata) {
+		b.log.Debug("job.action", "skip", "job.id", job.Id, "reason", "guard")
+		return
+	}
+
+	b.log.Debug("job.action", "execute", "job.id", job.Id)
+	b.executeJob(job, scheduled)
 }
 
 // 开始执行任务，任务将在协程中执行
-func (b *Beat) executeJob(job *job) {
-	if b.sem != nil {
-		b.sem.Acquire(b.ctx, 1)
+//
+// scheduled 为本次执行对应的运行时间，通过上下文传递给任务
+func (b *Beat) executeJob(job *job, scheduled time.Time) {
+	// 已经开始停止时不再执行新的任务，避免停止时等待刚开始的任务
+	if b.stopping.Load() {
+		b.log.Debug("job.action", "skip", "job.id", job.Id, "reason", "stopping")
+		return
 	}
 
+	if job.skipIfRunning && !job.running.CompareAndSwap(false, true) {
+		b.log.Debug("job.action", "skip", "job.id", job.Id, "reason", "still-running")
+		return
+	}
+
+	ctx := b.jobCtx
+	// 在调度协程中读取用户数据，保证与 SetUserdata 同步
+	userdata := job.Userdata
+	// 信号量可能被 SetMaxGoroutines 替换，任务始终释放获取时的信号量
+	sem := b.sem
+	// 权重不超过信号量的大小，防止 SetMaxGoroutines 缩小限制后永远无法获取
+	weight := min(max(job.weight, 1), int64(b.maxGoroutines))
+	// 只有存在下游任务时才在执行结束后通知调度协程
+	chained := b.hasDownstream(job.Id)
+
 	b.jobWaiter.Add(1)
+	b.metrics.goroutines.Add(1)
 
 	go func() {
+		defer b.metrics.goroutines.Add(-1)
+
 		if b.withRecovery {
 			defer func() {
 				if r := recover(); r != nil {
-					buf := make([]byte, 64<<10)
-					n := runtime.Stack(buf, false)
-					buf = buf[:n]
-					b.log.Error("panic", r, "statck", string(buf))
+					if b.panicHandler != nil {
+						b.panicHandler(job.Id, r, stack(b.stackSize))
+					} else {
+						b.log.Error("panic", r, "statck", string(stack(b.stackSize)))
+					}
+					b.metrics.panics.Add(1)
+					b.emit(EventPanicked, job.Id)
 				}
 			}()
 		}
 
 		defer b.jobWaiter.Done()
 
-		if b.sem != nil {
-			defer b.sem.Release(1)
+		// 每次执行使用单独的上下文，以便通过 Cancel 取消
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		defer b.runCancels.add(job.Id, cancel)()
+
+		if job.skipIfRunning {
+			defer job.running.Store(false)
 		}
 
-		job.Func(b.ctx, job.Userdata)
-	}()
-}
+		if job.delayIfRunning {
+			job.runLock.Lock()
+			defer job.runLock.Unlock()
+		}
 
-func (b *Beat) addJob(job *job) {
-	found := b.find(job.Id)
-	if found != nil {
-		b.log.Warn("msg", "job already exists, overwrite the old one", "job.id", found.Id)
-		b.removeJob(found.Id)
-	}
+		// 在协程中获取信号量，防止达到最大协程数量时阻塞调度
+		if sem != nil {
+			if err := sem.Acquire(ctx, weight); err != nil {
+				return
+			}
+			defer sem.Release(weight)
+		}
+
+		// 同样在协程中等待令牌，限制任务开始执行的速率
+		if b.limiter != nil {
+			if err := b.limiter.Wait(ctx); err != nil {
+				return
+			}
+		}
 
-	b.jobs = append(b.jobs, job)
+		if b.beforeJob != nil {
+			b.beforeJob(job.Id)
+		}
+		if b.afterJob != nil {
+			start := b.clock.Now()
+			defer func() {
+				b.afterJob(job.Id, b.clock.Now().Sub(start))
+			}()
+		}
+
+		// 先按 panic 记录，正常返回时替换为实际结果
+		start, err := b.clock.Now(), errJobPanicked
+		defer func() {
+			job.stats.record(start, b.clock.Now().Sub(start), err)
+		}()
+
+		b.metrics.active.Add(1)
+		defer b.metrics.active.Add(-1)
+
+		b.metrics.executions.Add(1)
+		b.emit(EventStarted, job.Id)
+		err = b.invokeJob(ctx, job, userdata, scheduled)
+		if chained {
+			b.notifyDownstream(job.Id, err != nil)
+		}
+		if err != nil {
+			b.metrics.errors.Add(1)
+		}
+		b.emit(EventCompleted, job.Id)
+	}()
 }
 
-// 移除任务
+// 执行任务，任务返回错误时按重试策略重试
 //
-// 返回移除的任务对象，不存在则返回 nil
-func (b *Beat) removeJob(id string) {
-	jobs := make([]*job, 0)
+// 返回最后一次执行的错误
+func (b *Beat) invokeJob(ctx context.Context, job *job, userdata any, scheduled time.Time) error {
+	for attempt := 1; ; attempt++ {
+		info := JobInfo{Id: job.Id, Scheduled: scheduled, Attempt: attempt}
+		err := b.callJob(withJobInfo(ctx, info), job, userdata)
+		if err == nil {
+			return nil
+		}
 
-	for _, job := range b.jobs {
-		if job.Id != id {
-			jobs = append(jobs, job)
+		if attempt > job.retries || ctx.Err() != nil {
+			b.handleError(job.Id, err)
+			return err
+		}
+
+		b.log.Warn("job.action", "retry", "job.id", job.Id, "job.attempt", attempt, "error", err)
+
+		var delay time.Duration
+		if job.backoff != nil {
+			delay = job.backoff(attempt)
+		}
+		if !b.sleep(ctx, delay) {
+			b.handleError(job.Id, err)
+			return err
 		}
 	}
-	b.jobs = jobs
 }
 
-// 移除全部任务
-func (b *Beat) removeAllJob() {
-	b.jobs = make([]*job, 0)
-}
+// 调用一次任务
+func (b *Beat) callJob(ctx context.Context, job *job, userdata any) error {
+	if job.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, job.timeout)
+		defer cancel()
+	}
 
-// 通过ID前缀移除任务，所有任务ID含有指定前缀的任务都将移除
-func (b *Beat) removeJobByPattern(pattern *regexp.Regexp) {
-	jobs := make([]*job, 0)
+	return job.Func(ctx, userdata)
+}
 
-	for _, job := range b.jobs {
-		if !pattern.MatchString(job.Id) {
-			jobs = append(jobs, job)
-		}
+// 等待一段时间，ctx 结束时提前返回 false
+func (b *Beat) sleep(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return true
 	}
 
-	b.jobs = jobs
+	timer := b.clock.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C():
+		return true
+	case <-ctx.Done():
+		return false
+	}
 }
 
-// 通过 ID 查找任务
+// 获取调用栈时的初始缓冲大小
+const initialStackSize = 4 << 10
+
+// 获取当前协程的完整调用栈
 //
-// 返回查找到的任务对象，不存在则返回 nil
-func (b *Beat) find(id string) *job {
-	for _, job := range b.jobs {
-		if job.Id == id {
-			return job
+// 缓冲不足时加倍后重新获取，limit 大于 0 时调用栈最多保留 limit 字节
+func stack(limit int) []byte {
+	size := initialStackSize
+	if limit > 0 {
+		size = min(size, limit)
+	}
+
+	for {
+		buf := make([]byte, size)
+		n := runtime.Stack(buf, false)
+		if n < size || (limit > 0 && size >= limit) {
+			return buf[:n]
+		}
+
+		size *= 2
+		if limit > 0 {
+			size = min(size, limit)
 		}
 	}
+}
+
+// 处理任务返回的错误，未设置错误处理函数时仅记录日志
+func (b *Beat) handleError(id string, err error) {
+	if b.errorHandler != nil {
+		b.errorHandler(id, err)
+		return
+	}
 
-	return nil
+	b.log.Error("job.action", "error", "job.id", id, "error", err)
 }
 
-// 添加任务
-//
-// 参数：
-//
-//	expr: 定时表达式
-//	id: 任务ID，每个任务ID唯一
-//	fn: 任务执行回调
-//	userdata: 用于保存用户数据，回调时将传递该数据
-func (b *Beat) Add(expr string, id string, fn JobFunc, userdata any) error {
-	sched, err := b.parser.Parse(expr)
-	if err != nil {
-		return err
+// 计算任务的下一次运行时间，暂停的任务不再运行；设置了随机延迟时在运行时间上追加随机延迟
+func (b *Beat) scheduleJob(job *job, now time.Time) {
+	if job.paused {
+		job.Next = time.Time{}
+		return
 	}
 
-	b.lock.Lock()
-	defer b.lock.Unlock()
+	job.Next = nextTime(job, now)
 
-	job := &job{
-		Id:       id,
-		Schedule: sched,
-		Func:     fn,
-		Userdata: userdata,
+	jitter := job.jitter
+	if jitter <= 0 {
+		jitter = b.jitter
 	}
-	if job.Func == nil {
-		job.Func = emptyJobFunc
+	if jitter <= 0 || job.Next.IsZero() {
+		return
 	}
 
-	if !b.running {
-		b.addJob(job)
-	} else {
-		b.operate <- opAdd(job)
+	// 随机延迟不能达到再下一次的运行时间，否则会错过该次运行
+	if following := job.Schedule.Next(job.Next); !following.IsZero() {
+		jitter = min(jitter, following.Sub(job.Next))
+	}
+	if jitter > 0 {
+		job.Next = job.Next.Add(rand.N(jitter))
 	}
-
-	return nil
 }
 
-// 移除任务
-func (b *Beat) Remove(id string) {
-	b.lock.Lock()
-	defer b.lock.Unlock()
+// 跳过禁止运行时间段时最多向后查找的次数
+const maxBlackoutSkips = 10000
 
-	if !b.running {
-		b.removeJob(id)
-	} else {
-		b.operate <- opRemove(id)
+// 获取任务在 t 之后的下一次运行时间，跳过禁止运行的时间段
+//
+// 查找 maxBlackoutSkips 次后仍在禁止运行的时间段中时返回零值时间
+func nextTime(job *job, t time.Time) time.Time {
+	next := job.Schedule.Next(t)
+	if job.blackout == nil {
+		return next
 	}
-}
-
-// 清空任务
-func (b *Beat) RemoveAll() {
-	b.lock.Lock()
-	defer b.lock.Unlock()
 
-	if !b.running {
-		b.removeAllJob()
-	} else {
-		b.operate <- opRemoveAll(struct{}{})
+	for range maxBlackoutSkips {
+		if next.IsZero() || !job.blackout(next) {
+			return next
+		}
+		next = job.Schedule.Next(next)
 	}
+
+	return time.Time{}
 }
 
-// 通过正则表达式移除任务
-func (b *Beat) RemoveByPattern(exp string) error {
-	b.lock.Lock()
-	defer b.lock.Unlock()
+// 补执行任务在上一次运行之后错过的运行，最多补执行 b.catchUp 次
+func (b *Beat) catchUpJob(job *job, now time.Time) {
+	if b.catchUp <= 0 || job.paused || job.Prev.IsZero() {
+		return
+	}
+
+	var missed []time.Time
+	last := job.Prev
+	for len(missed) < b.catchUp {
+		next := nextTime(job, last)
+		if next.IsZero() || next.After(now) {
+			break
+		}
+
+		// 先计数再计算下一次，有限次数的定时不会补执行超过剩余次数的运行
+		notifyFired(job, next)
+		missed = append(missed, next)
+		last = next
+	}
+
+	if len(missed) == 0 {
+		return
+	}
+
+	b.log.Info("job.action", "catch-up", "job.id", job.Id, "job.missed", len(missed))
+
+	for _, scheduled := range missed {
+		b.fireJob(job, scheduled)
+	}
+	job.Prev = last
+}
+
+// 执行所有已经到定时的任务，并重新计算下一次运行时间
+//
+// 先取出所有已经到定时的任务，再逐个执行并重新计算下一次运行时间，
+// 保证同一时间到定时的任务在一次调用中都执行，且只执行一次
+func (b *Beat) fireDueJobs(now time.Time) {
+	for _, job := range b.popDueJobs(now) {
+		b.fireJob(job, job.Next)
+		notifyFired(job, job.Next)
+
+		job.Prev = job.Next
+		b.scheduleJob(job, now)
+
+		if (b.autoRemove || b.autoPrune || job.once) && isFinished(job) {
+			b.trackDownstream(job, -1)
+			b.emit(EventRemoved, job.Id)
+			b.log.Info("job.action", "remove-finished", "job.id", job.Id)
+			continue
+		}
+
+		heap.Push(&b.jobs, job)
+	}
+}
+
+// 从堆中取出所有已经到定时的任务
+func (b *Beat) popDueJobs(now time.Time) []*job {
+	due := make([]*job, 0)
+
+	for len(b.jobs) > 0 {
+		next := b.jobs[0].Next
+		if next.After(now) || next.IsZero() {
+			break
+		}
+		due = append(due, heap.Pop(&b.jobs).(*job))
+	}
+
+	return due
+}
+
+// 添加任务
+//
+// 任务ID已存在时，若启用了 rejectDup 则返回 ErrJobExist，否则覆盖旧任务
+func (b *Beat) addJob(job *job) error {
+	if err := b.checkWeight(job); err != nil {
+		return err
+	}
+
+	found := b.find(job.Id)
+	if found != nil && b.rejectDup {
+		return fmt.Errorf("%w: %s", ErrJobExist, job.Id)
+	}
+	if err := b.checkAfter(job); err != nil {
+		return err
+	}
+
+	if found != nil {
+		b.log.Warn("msg", "job already exists, overwrite the old one", "job.id", found.Id)
+		b.removeJob(found.Id)
+	}
+
+	b.seq++
+	job.seq = b.seq
+
+	heap.Push(&b.jobs, job)
+	b.trackDownstream(job, 1)
+	b.emit(EventAdded, job.Id)
+
+	return nil
+}
+
+// 检查任务的权重，限制了最大协程数量且权重超过该数量时返回 ErrInvalidWeight
+func (b *Beat) checkWeight(job *job) error {
+	if b.maxGoroutines > 0 && job.weight > int64(b.maxGoroutines) {
+		return fmt.Errorf("%w: %s", ErrInvalidWeight, job.Id)
+	}
+
+	return nil
+}
+
+// 移除任务
+func (b *Beat) removeJob(id string) {
+	job := b.find(id)
+	if job != nil {
+		heap.Remove(&b.jobs, job.index)
+		b.trackDownstream(job, -1)
+		b.emit(EventRemoved, id)
+	}
+}
+
+// 移除全部任务
+func (b *Beat) removeAllJob() {
+	for _, job := range b.jobs {
+		b.emit(EventRemoved, job.Id)
+	}
+
+	b.jobs = jobHeap{}
+	clear(b.downstream)
+}
+
+// 通过正则表达式移除任务，所有任务ID匹配正则表达式的任务都将移除
+//
+// 正则表达式未锚定时匹配任务ID的任意部分
+func (b *Beat) removeJobByPattern(pattern *regexp.Regexp) {
+	b.removeJobIf(matchPattern(pattern))
+}
+
+// 返回判断任务ID是否匹配正则表达式的函数，RemoveByPattern 与 EntriesByPattern 使用相同的匹配规则
+func matchPattern(pattern *regexp.Regexp) func(*job) bool {
+	return func(job *job) bool {
+		return pattern.MatchString(job.Id)
+	}
+}
+
+// 通过ID前缀移除任务，所有任务ID以指定前缀开始的任务都将移除
+func (b *Beat) removeJobByPrefix(prefix string) {
+	b.removeJobIf(func(job *job) bool {
+		return strings.HasPrefix(job.Id, prefix)
+	})
+}
+
+// 移除含有指定标签的任务
+func (b *Beat) removeJobByTag(tag string) {
+	b.removeJobIf(func(job *job) bool {
+		return slices.Contains(job.tags, tag)
+	})
+}
+
+// 启用 autoPrune 时，若重新计算运行时间后任务已运行结束则将其移除
+//
+// job 须为堆中的任务，已被同ID的任务覆盖或已移除时忽略
+func (b *Beat) pruneJob(job *job) {
+	if !b.autoPrune || job == nil || !isFinished(job) {
+		return
+	}
+	if job.index < 0 || job.index >= len(b.jobs) || b.jobs[job.index] != job {
+		return
+	}
+
+	heap.Remove(&b.jobs, job.index)
+	b.trackDownstream(job, -1)
+	b.emit(EventRemoved, job.Id)
+	b.log.Info("job.action", "prune", "job.id", job.Id)
+}
+
+// 移除所有运行结束的任务
+func (b *Beat) pruneFinished() {
+	if !slices.ContainsFunc(b.jobs, isFinished) {
+		return
+	}
+
+	removed := b.removeJobIf(isFinished)
+	b.log.Info("job.action", "prune", "job.count", removed)
+}
+
+// 移除所有满足条件的任务，返回移除的任务数量
+func (b *Beat) removeJobIf(match func(*job) bool) int {
+	jobs := make(jobHeap, 0)
+
+	for _, job := range b.jobs {
+		if !match(job) {
+			job.index = len(jobs)
+			jobs = append(jobs, job)
+		} else {
+			b.trackDownstream(job, -1)
+			b.emit(EventRemoved, job.Id)
+		}
+	}
+
+	removed := len(b.jobs) - len(jobs)
+	b.jobs = jobs
+	heap.Init(&b.jobs)
+
+	return removed
+}
+
+// 更新任务的定时时间
+func (b *Beat) updateJob(id string, expr string, sched Schedule) (*job, error) {
+	job := b.find(id)
+	if job == nil {
+		return nil, fmt.Errorf("%w: %s", ErrJobNotExist, id)
+	}
+
+	job.Expr = expr
+	job.Schedule = sched
+
+	return job, nil
+}
+
+// 暂停任务，已暂停的任务不做处理
+func (b *Beat) pauseJob(id string) error {
+	job := b.find(id)
+	if job == nil {
+		return fmt.Errorf("%w: %s", ErrJobNotExist, id)
+	}
+
+	job.paused = true
+	job.Next = time.Time{}
+	heap.Fix(&b.jobs, job.index)
+
+	return nil
+}
+
+// 恢复任务，并从 now 开始重新计算下一次运行时间
+func (b *Beat) resumeJob(id string, now time.Time) error {
+	job := b.find(id)
+	if job == nil {
+		return fmt.Errorf("%w: %s", ErrJobNotExist, id)
+	}
+
+	if !job.paused {
+		return nil
+	}
+
+	job.paused = false
+	b.scheduleJob(job, now)
+	heap.Fix(&b.jobs, job.index)
+
+	return nil
+}
+
+// 暂停全部任务
+func (b *Beat) pauseAllJob() {
+	for _, job := range b.jobs {
+		job.paused = true
+		job.Next = time.Time{}
+	}
+}
+
+// 恢复全部暂停的任务，并从 now 开始重新计算下一次运行时间
+func (b *Beat) resumeAllJob(now time.Time) {
+	for _, job := range b.jobs {
+		if job.paused {
+			job.paused = false
+			b.scheduleJob(job, now)
+		}
+	}
+	heap.Init(&b.jobs)
+}
+
+// 设置最大协程数量，不大于 0 时不限制
+//
+// 使用新的信号量替换旧的信号量，正在执行和等待的任务仍使用旧的信号量
+func (b *Beat) setMaxGoroutines(n int) {
+	b.maxGoroutines = max(n, 0)
+
+	if b.maxGoroutines > 0 {
+		b.sem = semaphore.NewWeighted(int64(b.maxGoroutines))
+	} else {
+		b.sem = nil
+	}
+}
+
+// 从 now 开始重新计算全部任务的下一次运行时间
+func (b *Beat) rescheduleAllJob(now time.Time) {
+	for _, job := range b.jobs {
+		b.scheduleJob(job, now)
+	}
+	heap.Init(&b.jobs)
+}
+
+// 更新任务的用户数据
+func (b *Beat) setJobUserdata(id string, userdata any) error {
+	job := b.find(id)
+	if job == nil {
+		return fmt.Errorf("%w: %s", ErrJobNotExist, id)
+	}
+
+	job.Userdata = userdata
+
+	return nil
+}
+
+// 立即执行任务，不影响任务的下一次运行时间
+func (b *Beat) runJobNow(id string) error {
+	job := b.find(id)
+	if job == nil {
+		return fmt.Errorf("%w: %s", ErrJobNotExist, id)
+	}
+
+	b.executeJob(job, b.now())
+
+	return nil
+}
+
+// 通过 ID 查找任务
+//
+// 返回查找到的任务对象，不存在则返回 nil
+func (b *Beat) find(id string) *job {
+	for _, job := range b.jobs {
+		if job.Id == id {
+			return job
+		}
+	}
+
+	return nil
+}
+
+// 获取所有任务的快照
+func (b *Beat) entries() []Entry {
+	entries := make([]Entry, 0, len(b.jobs))
+
+	for _, job := range b.jobs {
+		entries = append(entries, newEntry(job))
+	}
+
+	return entries
+}
+
+// 按下一次运行时间的顺序获取最多 n 个任务的快照，不包括没有下一次运行时间的任务
+func (b *Beat) upcoming(n int) []Entry {
+	jobs := make(jobHeap, 0, len(b.jobs))
+	for _, job := range b.jobs {
+		if !job.Next.IsZero() {
+			jobs = append(jobs, job)
+		}
+	}
+	// 与堆使用相同的顺序，运行时间相同时按优先级和添加顺序排列
+	sort.Slice(jobs, jobs.Less)
+
+	entries := make([]Entry, 0, min(n, len(jobs)))
+	for _, job := range jobs[:min(n, len(jobs))] {
+		entries = append(entries, newEntry(job))
+	}
+
+	return entries
+}
+
+// 获取含有指定标签的任务的快照
+func (b *Beat) entriesByTag(tag string) []Entry {
+	return b.entriesIf(func(job *job) bool {
+		return slices.Contains(job.tags, tag)
+	})
+}
+
+// 获取任务ID匹配正则表达式的任务的快照
+func (b *Beat) entriesByPattern(pattern *regexp.Regexp) []Entry {
+	return b.entriesIf(matchPattern(pattern))
+}
+
+// 获取所有满足条件的任务的快照
+func (b *Beat) entriesIf(match func(*job) bool) []Entry {
+	entries := make([]Entry, 0)
+
+	for _, job := range b.jobs {
+		if match(job) {
+			entries = append(entries, newEntry(job))
+		}
+	}
+
+	return entries
+}
+
+// 获取指定任务的快照
+//
+// 不存在则返回 nil
+func (b *Beat) entry(id string) *Entry {
+	job := b.find(id)
+	if job == nil {
+		return nil
+	}
+
+	entry := newEntry(job)
+	return &entry
+}
+
+func newEntry(job *job) Entry {
+	return Entry{
+		Id:       job.Id,
+		Expr:     job.Expr,
+		Schedule: job.Schedule,
+		Next:     job.Next,
+		Prev:     job.Prev,
+		Tags:     slices.Clone(job.tags),
+		Paused:   job.paused,
+		Stats:    job.stats.snapshot(),
+	}
+}
+
+// 添加任务
+//
+// 参数：
+//
+//	expr: 定时表达式
+//	id: 任务ID，每个任务ID唯一
+//	fn: 任务执行回调
+//	userdata: 用于保存用户数据，回调时将传递该数据
+//	opts: 任务选项
+//
+// 任务ID为空时返回 ErrEmptyId；启用 WithRejectDuplicates 时，任务ID已存在则返回 ErrJobExist
+func (b *Beat) Add(expr string, id string, fn JobFunc, userdata any, opts ...JobOption) error {
+	return b.add(expr, id, toJobFuncE(fn), userdata, opts)
+}
+
+// 添加不需要用户数据的任务
+//
+// 参数与 Add 相同
+func (b *Beat) AddFunc(expr string, id string, fn func(ctx context.Context), opts ...JobOption) error {
+	var fnE JobFuncE
+	if fn != nil {
+		fnE = func(ctx context.Context, _ any) error {
+			fn(ctx)
+			return nil
+		}
+	}
+
+	return b.add(expr, id, fnE, nil, opts)
+}
+
+// 添加返回错误的任务
+//
+// 参数与 Add 相同，任务返回的非 nil 错误将传递给 WithErrorHandler 设置的错误处理函数
+func (b *Beat) AddE(expr string, id string, fn JobFuncE, userdata any, opts ...JobOption) error {
+	return b.add(expr, id, fn, userdata, opts)
+}
+
+func (b *Beat) add(expr string, id string, fn JobFuncE, userdata any, opts []JobOption) error {
+	job, err := b.parseJob(expr, id, fn, userdata, opts)
+	if err != nil {
+		return err
+	}
+
+	return b.submitJob(job)
+}
+
+// 解析定时表达式并创建任务，最小间隔在提交时检查
+//
+// 任务ID为空时返回 ErrEmptyId
+func (b *Beat) parseJob(expr string, id string, fn JobFuncE, userdata any, opts []JobOption) (*job, error) {
+	if id == "" {
+		return nil, ErrEmptyId
+	}
+
+	sched, err := b.parser.Parse(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	return b.newJob(expr, sched, id, fn, userdata, opts), nil
+}
+
+// 使用已解析的定时时间添加任务，expr 仅用于记录
+func (b *Beat) addSchedule(expr string, sched Schedule, id string, fn JobFuncE, userdata any, opts []JobOption) error {
+	return b.submitJob(b.newJob(expr, sched, id, fn, userdata, opts))
+}
+
+// 检查最小间隔并添加已创建的任务，运行中时提交给调度协程
+func (b *Beat) submitJob(job *job) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if err := b.checkInterval(job.Schedule); err != nil {
+		return err
+	}
+
+	if !b.running {
+		return b.addJob(job)
+	}
+
+	reply := make(chan error)
+	b.operate <- opAdd{job: job, reply: reply}
+
+	return <-reply
+}
+
+// 检查定时时间的最小间隔，从现在开始的前两次运行时间的间隔小于 minInterval 时返回 ErrTooFrequent
+//
+// 从调度器时区的当前时间开始计算；未设置 minInterval 或运行次数少于两次时不检查。调用前需持有互斥锁
+func (b *Beat) checkInterval(sched Schedule) error {
+	if b.minInterval <= 0 {
+		return nil
+	}
+
+	first := sched.Next(b.clock.Now().In(b.location))
+	if first.IsZero() {
+		return nil
+	}
+	second := sched.Next(first)
+	if second.IsZero() {
+		return nil
+	}
+
+	if interval := second.Sub(first); interval < b.minInterval {
+		return fmt.Errorf("%w: interval %s is less than %s", ErrTooFrequent, interval, b.minInterval)
+	}
+
+	return nil
+}
+
+// 创建任务，并应用任务选项和 JobWrapper
+func (b *Beat) newJob(expr string, sched Schedule, id string, fn JobFuncE, userdata any, opts []JobOption) *job {
+	job := &job{
+		Id:       id,
+		Expr:     expr,
+		Schedule: sched,
+		Func:     fn,
+		Userdata: userdata,
+	}
+	if job.Func == nil {
+		job.Func = emptyJobFunc
+	}
+
+	for _, opt := range opts {
+		opt(job)
+	}
+
+	wrappers := append(slices.Clone(b.chain), job.wrappers...)
+	job.Func = wrapJob(job.Func, wrappers)
+
+	return job
+}
+
+// 使用自定义的定时时间添加任务，不经过解析器
+//
+// 参数与 Add 相同。任务的定时表达式为空，不会被 Save 保存；sched 为 nil 时返回 ErrNilSchedule
+func (b *Beat) AddSchedule(sched Schedule, id string, fn JobFunc, userdata any, opts ...JobOption) error {
+	if id == "" {
+		return ErrEmptyId
+	}
+	if sched == nil {
+		return ErrNilSchedule
+	}
+
+	return b.addSchedule("", sched, id, toJobFuncE(fn), userdata, opts)
+}
+
+// 添加以多个定时表达式的并集运行的任务
+//
+// 参数与 Add 相同，多个表达式在同一时间到达时只运行一次。
+// 任意一个表达式无效时返回错误；任务的定时表达式为空，不会被 Save 保存
+func (b *Beat) AddMulti(exprs []string, id string, fn JobFunc, userdata any, opts ...JobOption) error {
+	if id == "" {
+		return ErrEmptyId
+	}
+	if len(exprs) == 0 {
+		return fmt.Errorf("%w: no expression", ErrInvalidExp)
+	}
+
+	scheds := make([]Schedule, 0, len(exprs))
+	for _, expr := range exprs {
+		sched, err := b.parser.Parse(expr)
+		if err != nil {
+			return err
+		}
+		scheds = append(scheds, sched)
+	}
+
+	return b.AddSchedule(Union(scheds...), id, fn, userdata, opts...)
+}
+
+// 获取任务数量
+func (b *Beat) Count() int {
+	return len(b.Entries())
+}
+
+// 判断任务是否存在
+func (b *Beat) Contains(id string) bool {
+	_, ok := b.Entry(id)
+	return ok
+}
+
+// 获取距离任务下一次运行的时间
+//
+// 任务不存在或没有下一次运行时间时第二个返回值为 false；任务已经逾期时返回负值
+func (b *Beat) TimeUntilNext(id string) (time.Duration, bool) {
+	entry, ok := b.Entry(id)
+	if !ok || entry.Next.IsZero() {
+		return 0, false
+	}
+
+	return entry.Next.Sub(b.clock.Now()), true
+}
+
+// 校验时间表达式，不添加任务
+func (b *Beat) Validate(expr string) error {
+	_, err := b.parser.Parse(expr)
+	return err
+}
+
+// NextN 可预览的最大次数
+const maxNextN = 1000
+
+// 预览时间表达式从当前时间开始的 n 次运行时间
+//
+// n 超过 1000 时按 1000 处理；表达式不再有有效时间时，返回的次数可能少于 n
+func (b *Beat) NextN(expr string, n int) ([]time.Time, error) {
+	if n <= 0 {
+		return nil, fmt.Errorf("%w: %d", ErrInvalidCount, n)
+	}
+	n = min(n, maxNextN)
+
+	sched, err := b.parser.Parse(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	times := make([]time.Time, 0, n)
+	next := b.clock.Now().In(b.Location())
+	for range n {
+		next = sched.Next(next)
+		if next.IsZero() {
+			break
+		}
+		times = append(times, next)
+	}
+
+	return times, nil
+}
+
+// 移除任务
+func (b *Beat) Remove(id string) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if !b.running {
+		b.removeJob(id)
+	} else {
+		b.operate <- opRemove(id)
+	}
+}
+
+// 清空任务
+func (b *Beat) RemoveAll() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if !b.running {
+		b.removeAllJob()
+	} else {
+		b.operate <- opRemoveAll(struct{}{})
+	}
+}
+
+// 通过正则表达式移除任务
+//
+// 正则表达式匹配任务ID的任意部分，如 "a.b" 也匹配 "xa-by"；需要完整匹配时使用 ^ 和 $ 锚定，
+// 按前缀移除时可使用 RemoveByPrefix，无需转义任务ID中的特殊字符
+func (b *Beat) RemoveByPattern(exp string) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	pattern, err := regexp.Compile(exp)
+	if err != nil {
+		return err
+	}
+
+	if !b.running {
+		b.removeJobByPattern(pattern)
+	} else {
+		b.operate <- opRemoveByPattern(pattern)
+	}
+
+	return nil
+}
+
+// 通过ID前缀移除任务，所有任务ID以指定前缀开始的任务都将移除
+//
+// 前缀按原样比较，不作为正则表达式；前缀为空时移除所有任务
+func (b *Beat) RemoveByPrefix(prefix string) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if !b.running {
+		b.removeJobByPrefix(prefix)
+	} else {
+		b.operate <- opRemoveByPrefix(prefix)
+	}
+}
+
+// 移除含有指定标签的任务
+func (b *Beat) RemoveByTag(tag string) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if !b.running {
+		b.removeJobByTag(tag)
+	} else {
+		b.operate <- opRemoveByTag(tag)
+	}
+}
+
+// 获取所有任务的快照
+//
+// 返回的切片为副本，修改不会影响内部状态；切片中任务的顺序不作保证
+func (b *Beat) Entries() []Entry {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if !b.running {
+		return b.entries()
+	}
+
+	reply := make(chan []Entry)
+	b.operate <- opSnapshot(reply)
+
+	return <-reply
+}
 
+// 获取任务ID匹配正则表达式的任务的快照，可在 RemoveByPattern 前确认将要移除的任务
+//
+// 匹配规则与 RemoveByPattern 相同；正则表达式无效时返回错误。返回的切片为副本，切片中任务的顺序不作保证
+func (b *Beat) EntriesByPattern(exp string) ([]Entry, error) {
 	pattern, err := regexp.Compile(exp)
 	if err != nil {
+		return nil, err
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if !b.running {
+		return b.entriesByPattern(pattern), nil
+	}
+
+	reply := make(chan []Entry)
+	b.operate <- opEntriesByPattern{pattern: pattern, reply: reply}
+
+	return <-reply, nil
+}
+
+// 获取含有指定标签的任务的快照
+//
+// 返回的切片为副本，切片中任务的顺序不作保证
+func (b *Beat) EntriesByTag(tag string) []Entry {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if !b.running {
+		return b.entriesByTag(tag)
+	}
+
+	reply := make(chan []Entry)
+	b.operate <- opEntriesByTag{tag: tag, reply: reply}
+
+	return <-reply
+}
+
+// 按下一次运行时间的顺序获取最多 n 个即将运行的任务的快照
+//
+// 不包括已暂停或没有下一次运行时间的任务；n 不大于 0 时返回空切片
+func (b *Beat) UpcomingN(n int) []Entry {
+	n = max(n, 0)
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if !b.running {
+		return b.upcoming(n)
+	}
+
+	reply := make(chan []Entry)
+	b.operate <- opUpcoming{n: n, reply: reply}
+
+	return <-reply
+}
+
+// 获取指定任务的快照
+//
+// 任务不存在时第二个返回值为 false
+func (b *Beat) Entry(id string) (Entry, bool) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	var entry *Entry
+	if !b.running {
+		entry = b.entry(id)
+	} else {
+		reply := make(chan *Entry)
+		b.operate <- opEntry{id: id, reply: reply}
+		entry = <-reply
+	}
+
+	if entry == nil {
+		return Entry{}, false
+	}
+
+	return *entry, true
+}
+
+// 更新任务的定时表达式，并重新计算下一次运行时间
+//
+// 任务的其他属性保持不变；任务不存在时返回 ErrJobNotExist
+func (b *Beat) Update(id string, expr string) error {
+	sched, err := b.parser.Parse(expr)
+	if err != nil {
+		return err
+	}
+
+	return b.update(id, expr, sched)
+}
+
+// 使用自定义的定时时间更新任务，并重新计算下一次运行时间
+//
+// 可以在任务执行时调用，如根据执行结果调整下一次运行的间隔。
+// 任务的定时表达式将被清空；任务不存在时返回 ErrJobNotExist，sched 为 nil 时返回 ErrNilSchedule
+func (b *Beat) Reschedule(id string, sched Schedule) error {
+	if sched == nil {
+		return ErrNilSchedule
+	}
+
+	return b.update(id, "", sched)
+}
+
+func (b *Beat) update(id string, expr string, sched Schedule) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if err := b.checkInterval(sched); err != nil {
 		return err
 	}
 
 	if !b.running {
-		b.removeJobByPattern(pattern)
+		_, err := b.updateJob(id, expr, sched)
+		return err
+	}
+
+	reply := make(chan error)
+	b.operate <- opUpdate{id: id, expr: expr, schedule: sched, reply: reply}
+
+	return <-reply
+}
+
+// 更新任务的用户数据，下一次执行时生效
+//
+// 任务不存在时返回 ErrJobNotExist
+func (b *Beat) SetUserdata(id string, userdata any) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if !b.running {
+		return b.setJobUserdata(id, userdata)
+	}
+
+	reply := make(chan error)
+	b.operate <- opSetUserdata{id: id, userdata: userdata, reply: reply}
+
+	return <-reply
+}
+
+// 暂停任务，任务保留但不再运行，暂停已暂停的任务不做处理
+//
+// 任务不存在时返回 ErrJobNotExist
+func (b *Beat) Pause(id string) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if !b.running {
+		return b.pauseJob(id)
+	}
+
+	reply := make(chan error)
+	b.operate <- opPause{id: id, reply: reply}
+
+	return <-reply
+}
+
+// 恢复暂停的任务，并从当前时间开始重新计算下一次运行时间
+//
+// 任务不存在时返回 ErrJobNotExist
+func (b *Beat) Resume(id string) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if !b.running {
+		return b.resumeJob(id, b.now())
+	}
+
+	reply := make(chan error)
+	b.operate <- opResume{id: id, reply: reply}
+
+	return <-reply
+}
+
+// 获取任务是否暂停
+//
+// 第二个返回值表示任务是否存在，任务不存在时第一个返回值为 false
+func (b *Beat) IsPaused(id string) (bool, bool) {
+	entry, ok := b.Entry(id)
+	return entry.Paused, ok
+}
+
+// 暂停全部任务，可重复调用
+//
+// 仅暂停当前已有的任务，之后添加的任务不会被暂停
+func (b *Beat) PauseAll() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if !b.running {
+		b.pauseAllJob()
 	} else {
-		b.operate <- opRemoveByPattern(pattern)
+		b.operate <- opPauseAll(struct{}{})
 	}
+}
 
-	return nil
+// 恢复全部暂停的任务（包括通过 Pause 暂停的任务），可重复调用
+func (b *Beat) ResumeAll() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if !b.running {
+		b.resumeAllJob(b.now())
+	} else {
+		b.operate <- opResumeAll(struct{}{})
+	}
 }
 
-// 停止运行
-func (b *Beat) Stop() {
+// 立即执行任务，不影响任务的下一次运行时间
+//
+// 任务不存在时返回 ErrJobNotExist
+func (b *Beat) RunNow(id string) error {
 	b.lock.Lock()
 	defer b.lock.Unlock()
 
+	if !b.running {
+		return b.runJobNow(id)
+	}
+
+	reply := make(chan error)
+	b.operate <- opRunNow{id: id, reply: reply}
+
+	return <-reply
+}
+
+// 停止运行，并等待正在执行的任务结束
+//
+// 传递给任务的上下文将被取消，任务应监听 ctx.Done() 以便及时退出
+func (b *Beat) Stop() {
+	b.StopWithContext(context.Background())
+}
+
+// 停止运行，并等待正在执行的任务结束
+//
+// 传递给任务的上下文将被取消，任务应监听 ctx.Done() 以便及时退出。
+// ctx 结束时不再等待，返回 ctx.Err()；此时调度已经停止，未退出的任务将继续执行
+func (b *Beat) StopWithContext(ctx context.Context) error {
+	b.lock.Lock()
+	if b.running {
+		// 通道有缓冲时，需要等待调度协程处理完之前的请求并退出
+		b.stopping.Store(true)
+		stopped := make(chan struct{})
+		b.operate <- opStop(stopped)
+		<-stopped
+		b.stopping.Store(false)
+		b.running = false
+		b.cancel()
+		// 停止后 RunNow 执行的任务使用新的上下文
+		b.renewJobCtx()
+	}
+	b.lock.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		b.jobWaiter.Wait()
+		close(done)
+	}()
+
+	// 事件通道在停止后关闭，之后执行结束的任务不再发送事件
+	defer b.closeEvents()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// 执行所有已经到定时的任务后停止运行，并等待所有任务结束
+//
+// 每个到定时的任务只执行一次，不会因为定时间隔很短而持续执行。
+// 与 Stop 不同，传递给任务的上下文在所有任务结束后才被取消
+func (b *Beat) StopAndDrain() {
+	b.lock.Lock()
+	cancel := func() {}
 	if b.running {
-		b.operate <- opStop(struct{}{})
+		cancel = b.cancel
+		stopped := make(chan struct{})
+		b.operate <- opDrain(stopped)
+		<-stopped
+		b.stopping.Store(false)
 		b.running = false
+		// 正在执行的任务持有旧的上下文，再次运行时使用新的上下文
+		b.jobCtx, b.cancel = context.WithCancel(b.ctx)
 	}
+	b.lock.Unlock()
+
+	defer b.closeEvents()
+
 	b.jobWaiter.Wait()
+	cancel()
+}
+
+// 上一次停止运行时取消了传递给任务的上下文，需要重新创建
+func (b *Beat) renewJobCtx() {
+	if b.jobCtx.Err() != nil {
+		b.jobCtx, b.cancel = context.WithCancel(b.ctx)
+	}
 }
 
 // 开始运行，beat 将在协程中运行
@@ -381,7 +1913,22 @@ func (b *Beat) Start() {
 	}
 
 	b.running = true
-	go b.run()
+	b.renewJobCtx()
+	go b.run(nil)
+}
+
+// 开始运行，beat 将在协程中运行，ctx 结束时停止运行
+func (b *Beat) StartContext(ctx context.Context) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.running {
+		return
+	}
+
+	b.running = true
+	b.renewJobCtx()
+	go b.run(ctx.Done())
 }
 
 // 开始运行，beat 将阻塞运行
@@ -394,8 +1941,125 @@ func (b *Beat) Run() {
 	}
 
 	b.running = true
+	b.renewJobCtx()
+	b.lock.Unlock()
+	b.run(nil)
+}
+
+// 开始运行，beat 将阻塞运行，ctx 结束时停止运行并返回
+func (b *Beat) RunContext(ctx context.Context) {
+	b.lock.Lock()
+
+	if b.running {
+		b.lock.Unlock()
+		return
+	}
+
+	b.running = true
+	b.renewJobCtx()
 	b.lock.Unlock()
-	b.run()
+	b.run(ctx.Done())
+}
+
+// 设置时区，运行中时将从当前时间开始重新计算全部任务的下一次运行时间
+//
+// loc 为 nil 时使用 time.Local
+func (b *Beat) SetLocation(loc *time.Location) {
+	if loc == nil {
+		loc = time.Local
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if !b.running {
+		b.location = loc
+		return
+	}
+
+	reply := make(chan struct{})
+	b.operate <- opSetLocation{location: loc, reply: reply}
+	<-reply
+}
+
+// 设置最大协程数量，不大于 0 时不限制
+//
+// 新的限制对之后开始的任务生效；正在执行和等待的任务仍受旧的限制，
+// 因此缩小限制后，实际执行的任务数量在旧任务结束前可能超过新的限制
+func (b *Beat) SetMaxGoroutines(n int) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if !b.running {
+		b.setMaxGoroutines(n)
+	} else {
+		b.operate <- opSetMaxGoroutines(n)
+	}
+}
+
+// 获取最大协程数量，0 表示不限制
+//
+// 与 ActiveJobs 一起可计算任务的饱和程度
+func (b *Beat) MaxGoroutines() int {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if !b.running {
+		return b.maxGoroutines
+	}
+
+	reply := make(chan int)
+	b.operate <- opMaxGoroutines(reply)
+
+	return <-reply
+}
+
+// 获取时区
+func (b *Beat) Location() *time.Location {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	return b.location
+}
+
+// 将已停止的调度器恢复到初始状态，以便重新运行
+//
+// 保留任务及其选项、暂停状态和调度器的配置；清空任务的执行统计、运行时间（包括 WithLastRun 设置的时间）
+// 和 Metrics 的计数器。需要同时清空任务时先调用 RemoveAll。运行中时返回 ErrRunning 且不做任何修改
+func (b *Beat) Reset() error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.running {
+		return ErrRunning
+	}
+
+	for _, job := range b.jobs {
+		job.Next = time.Time{}
+		job.Prev = time.Time{}
+		job.stats.reset()
+	}
+	b.metrics.reset()
+	b.renewJobCtx()
+
+	return nil
+}
+
+// 等待调度协程处理完之前提交的所有请求
+//
+// 使用 WithOperateBuffer 时，Remove 等没有返回值的请求在提交后即返回，Sync 返回时这些请求都已生效；
+// 未运行时请求直接生效，立即返回
+func (b *Beat) Sync() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if !b.running {
+		return
+	}
+
+	done := make(chan struct{})
+	b.operate <- opBarrier(done)
+	<-done
 }
 
 // 获取运行状态
@@ -406,7 +2070,12 @@ func (b *Beat) IsRunning() bool {
 	return b.running
 }
 
+// 设置 logger，log 为 nil 时使用 NopLogger
 func (b *Beat) SetLogger(log Logger) {
+	if log == nil {
+		log = NopLogger{}
+	}
+
 	b.lock.Lock()
 	defer b.lock.Unlock()
 
@@ -1,12 +1,18 @@
 package beat
 
 import (
+	"bytes"
+	"container/heap"
 	"context"
+	"errors"
 	"fmt"
+	"slices"
 	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
+
+	"golang.org/x/time/rate"
 )
 
 // Many tests schedule a job for every second, and then wait at most a second
@@ -192,6 +198,66 @@ func TestRemoveByPattern(t *testing.T) {
 	}
 }
 
+// Query jobs by pattern before removing them, expect the same jobs are matched and removed.
+func TestEntriesByPattern(t *testing.T) {
+	beat := New()
+	beat.Add("* * * * * * *", "TestEntriesByPattern-1", nil, nil)
+	beat.Add("* * * * * * *", "TestEntriesByPattern-2", nil, nil)
+	beat.Add("* * * * * * *", "Other-1", nil, nil)
+	beat.Start()
+	defer beat.Stop()
+
+	if _, err := beat.EntriesByPattern("("); err == nil {
+		t.Error("expected error for an invalid pattern")
+	}
+
+	entries, err := beat.EntriesByPattern("ByPattern-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, entry := range entries {
+		ids = append(ids, entry.Id)
+	}
+	slices.Sort(ids)
+	if !slices.Equal(ids, []string{"TestEntriesByPattern-1", "TestEntriesByPattern-2"}) {
+		t.Errorf("unexpected matched jobs %v", ids)
+	}
+	if beat.Count() != 3 {
+		t.Errorf("expected no job is removed, got %d", beat.Count())
+	}
+
+	beat.RemoveByPattern("ByPattern-")
+	if entries, _ := beat.EntriesByPattern("ByPattern-"); len(entries) != 0 || beat.Count() != 1 {
+		t.Errorf("expected matched jobs are removed, got %v", entries)
+	}
+}
+
+// Remove jobs by a prefix containing regex metacharacters, expect only ids with the literal prefix are removed.
+func TestRemoveByPrefix(t *testing.T) {
+	beat := New()
+	beat.Add("* * * * * * *", "svc.a+1", nil, nil)
+	beat.Add("* * * * * * *", "svc.a+2", nil, nil)
+	beat.Add("* * * * * * *", "svcxa+3", nil, nil)
+	beat.Add("* * * * * * *", "other.svc.a+4", nil, nil)
+
+	beat.RemoveByPrefix("svc.a+")
+	if beat.Contains("svc.a+1") || beat.Contains("svc.a+2") {
+		t.Error("expected jobs with the prefix are removed")
+	}
+	if !beat.Contains("svcxa+3") || !beat.Contains("other.svc.a+4") {
+		t.Error("expected jobs without the prefix are kept")
+	}
+
+	beat.Start()
+	defer beat.Stop()
+
+	beat.RemoveByPrefix("other.")
+	if beat.Count() != 1 || !beat.Contains("svcxa+3") {
+		t.Errorf("expected only svcxa+3 is kept, got %v", beat.Entries())
+	}
+}
+
 // Test that the jobs are correctly sorted.
 // Add a bunch of long-in-the-future jobs, and an immediate job, and ensure
 // that the immediate job runs immediately.
@@ -420,6 +486,36 @@ func TestRecovery(t *testing.T) {
 	time.Sleep(3 * time.Second)
 }
 
+// Panic in a job without recovery options, expect the panic is recovered by default.
+func TestRecoveryByDefault(t *testing.T) {
+	recovered := make(chan any, 1)
+
+	beat := New(WithPanicHandler(func(id string, r any, stack []byte) {
+		recovered <- r
+	}))
+	beat.Add("* 1 1 * 0 0 0", "TestRecoveryByDefault", func(ctx context.Context, userdata any) {
+		panic("panic in beat")
+	}, nil)
+
+	beat.Start()
+	defer beat.Stop()
+
+	beat.RunNow("TestRecoveryByDefault")
+
+	select {
+	case r := <-recovered:
+		if r != "panic in beat" {
+			t.Errorf("unexpected recovered value %v", r)
+		}
+	case <-time.After(OneSecond):
+		t.Fatal("expected panic is recovered")
+	}
+
+	if New(WithoutRecovery()).withRecovery {
+		t.Error("expected recovery is disabled")
+	}
+}
+
 func TestMaxGoroutines(t *testing.T) {
 	wg := &sync.WaitGroup{}
 	wg.Add(3)
@@ -451,3 +547,1954 @@ func TestMaxGoroutines(t *testing.T) {
 		t.Fatal("expected 2 jobs to run")
 	}
 }
+
+// Add jobs, query entries before and while running.
+func TestEntries(t *testing.T) {
+	beat := New()
+	beat.Add("* * * * * * *", "TestEntries-1", nil, nil)
+	beat.Add("* 1 1 * 0 0 0", "TestEntries-2", nil, nil)
+
+	entries := beat.Entries()
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+
+	beat.Start()
+	defer beat.Stop()
+
+	entries = beat.Entries()
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	for _, entry := range entries {
+		if entry.Next.IsZero() {
+			t.Errorf("expected entry %s to be scheduled", entry.Id)
+		}
+	}
+
+	// The returned slice is a copy.
+	entries[0].Id = "modified"
+	for _, entry := range beat.Entries() {
+		if entry.Id == "modified" {
+			t.Fatal("expected entries to be a copy")
+		}
+	}
+}
+
+// Query a single job before and while running.
+func TestEntry(t *testing.T) {
+	id := "TestEntry-1"
+
+	beat := New()
+	beat.Add("* * * * * * *", id, nil, nil)
+
+	if _, ok := beat.Entry("TestEntry-none"); ok {
+		t.Fatal("expected unknown job does not exist")
+	}
+
+	beat.Start()
+	defer beat.Stop()
+
+	entry, ok := beat.Entry(id)
+	if !ok {
+		t.Fatal("expected job exists")
+	}
+	if entry.Id != id || entry.Next.IsZero() {
+		t.Errorf("unexpected entry: %+v", entry)
+	}
+
+	if _, ok := beat.Entry("TestEntry-none"); ok {
+		t.Fatal("expected unknown job does not exist")
+	}
+}
+
+// Add jobs with empty or duplicate ids.
+func TestAddInvalidId(t *testing.T) {
+	beat := New(WithRejectDuplicates())
+
+	if err := beat.Add("* * * * * * *", "", nil, nil); !errors.Is(err, ErrEmptyId) {
+		t.Fatalf("expected ErrEmptyId, got %v", err)
+	}
+
+	id := "TestAddInvalidId-1"
+	if err := beat.Add("* * * * * * *", id, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := beat.Add("* * * * * * *", id, nil, nil); !errors.Is(err, ErrJobExist) {
+		t.Fatalf("expected ErrJobExist, got %v", err)
+	}
+
+	beat.Start()
+	defer beat.Stop()
+
+	if err := beat.Add("* * * * * * *", id, nil, nil); !errors.Is(err, ErrJobExist) {
+		t.Fatalf("expected ErrJobExist while running, got %v", err)
+	}
+	if n := len(beat.Entries()); n != 1 {
+		t.Fatalf("expected 1 entry, got %d", n)
+	}
+}
+
+// Add a duplicate id without WithRejectDuplicates, expect it overwrites.
+func TestAddOverwrite(t *testing.T) {
+	beat := New()
+
+	id := "TestAddOverwrite-1"
+	beat.Add("* 1 1 * 0 0 0", id, nil, nil)
+	if err := beat.Add("* * * * * * *", id, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(beat.Entries()); n != 1 {
+		t.Fatalf("expected 1 entry, got %d", n)
+	}
+}
+
+// Add a job returning an error, expect the error handler receives it.
+func TestErrorHandler(t *testing.T) {
+	id := "TestErrorHandler-1"
+	errJob := errors.New("job failed")
+	ch := make(chan error, 1)
+
+	beat := New(WithErrorHandler(func(jobId string, err error) {
+		if jobId == id {
+			select {
+			case ch <- err:
+			default:
+			}
+		}
+	}))
+
+	beat.AddE("* * * * * * *", id,
+		func(ctx context.Context, userdata any) error { return errJob },
+		nil)
+	beat.Start()
+	defer beat.Stop()
+
+	select {
+	case <-time.After(OneSecond):
+		t.Fatal("expected error handler is called")
+	case err := <-ch:
+		if !errors.Is(err, errJob) {
+			t.Errorf("expected %v, got %v", errJob, err)
+		}
+	}
+}
+
+// Add a long-running job that skips overlapping executions, expect it runs once.
+func TestSkipIfStillRunning(t *testing.T) {
+	var calls int64
+
+	beat := New()
+	beat.Add("* * * * * * *", "TestSkipIfStillRunning-1",
+		func(ctx context.Context, userdata any) {
+			atomic.AddInt64(&calls, 1)
+			time.Sleep(2500 * time.Millisecond)
+		},
+		nil, WithSkipIfStillRunning())
+	beat.Start()
+
+	<-time.After(2500 * time.Millisecond)
+	beat.Stop()
+
+	if n := atomic.LoadInt64(&calls); n != 1 {
+		t.Errorf("called %d times, expected 1", n)
+	}
+}
+
+// Add a long-running job that delays overlapping executions, expect executions
+// are serialized but still run.
+func TestDelayIfStillRunning(t *testing.T) {
+	var calls, active, overlapped int64
+
+	beat := New()
+	beat.Add("* * * * * * *", "TestDelayIfStillRunning-1",
+		func(ctx context.Context, userdata any) {
+			if atomic.AddInt64(&active, 1) > 1 {
+				atomic.StoreInt64(&overlapped, 1)
+			}
+			time.Sleep(1200 * time.Millisecond)
+			atomic.AddInt64(&active, -1)
+			atomic.AddInt64(&calls, 1)
+		},
+		nil, WithDelayIfStillRunning())
+	beat.Start()
+
+	<-time.After(2500 * time.Millisecond)
+	beat.Stop()
+
+	if atomic.LoadInt64(&overlapped) != 0 {
+		t.Error("expected executions do not overlap")
+	}
+	if n := atomic.LoadInt64(&calls); n < 2 {
+		t.Errorf("called %d times, expected at least 2", n)
+	}
+}
+
+// Add a far-future job, run it now, expect it runs and its schedule is kept.
+func TestRunNow(t *testing.T) {
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	id := "TestRunNow-1"
+
+	beat := New()
+	beat.Add("* 1 1 * 0 0 0", id,
+		func(ctx context.Context, userdata any) { wg.Done() },
+		nil)
+	beat.Start()
+	defer beat.Stop()
+
+	before, _ := beat.Entry(id)
+
+	if err := beat.RunNow(id); err != nil {
+		t.Fatal(err)
+	}
+	if err := beat.RunNow("TestRunNow-none"); !errors.Is(err, ErrJobNotExist) {
+		t.Fatalf("expected ErrJobNotExist, got %v", err)
+	}
+
+	select {
+	case <-time.After(OneSecond):
+		t.Fatal("expected job runs")
+	case <-wait(wg):
+	}
+
+	after, _ := beat.Entry(id)
+	if !after.Next.Equal(before.Next) {
+		t.Errorf("expected next %s unchanged, got %s", before.Next, after.Next)
+	}
+}
+
+// Preview upcoming fire times.
+func TestNextN(t *testing.T) {
+	beat := New()
+
+	times, err := beat.NextN("* * * * * * */10", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(times) != 3 {
+		t.Fatalf("expected 3 times, got %d", len(times))
+	}
+	for i, tm := range times {
+		if tm.Second()%10 != 0 {
+			t.Errorf("unexpected time %s", tm)
+		}
+		if i > 0 && tm.Sub(times[i-1]) != 10*time.Second {
+			t.Errorf("expected 10s between %s and %s", times[i-1], tm)
+		}
+	}
+
+	times, err = beat.NextN("* * * * * * *", maxNextN+1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(times) != maxNextN {
+		t.Errorf("expected %d times, got %d", maxNextN, len(times))
+	}
+
+	if _, err := beat.NextN("* * * * * * *", 0); !errors.Is(err, ErrInvalidCount) {
+		t.Errorf("expected ErrInvalidCount, got %v", err)
+	}
+	if _, err := beat.NextN("invalid", 1); !errors.Is(err, ErrInvalidExp) {
+		t.Errorf("expected ErrInvalidExp, got %v", err)
+	}
+}
+
+// Push jobs into the heap, expect they pop in order of next time, unscheduled last.
+func TestJobHeap(t *testing.T) {
+	base := time.Now()
+	offsets := []int{5, -1, 3, 0, 9, -1, 1}
+
+	h := jobHeap{}
+	for i, offset := range offsets {
+		job := &job{Id: fmt.Sprint(i)}
+		if offset >= 0 {
+			job.Next = base.Add(time.Duration(offset) * time.Second)
+		}
+		heap.Push(&h, job)
+	}
+
+	prev := base.Add(-time.Second)
+	for range offsets {
+		job := heap.Pop(&h).(*job)
+		if job.Next.IsZero() {
+			prev = time.Time{}
+			continue
+		}
+		if prev.IsZero() || job.Next.Before(prev) {
+			t.Fatalf("job %s popped out of order", job.Id)
+		}
+		prev = job.Next
+	}
+}
+
+// Saturate max goroutines with a blocked job, expect the scheduler still
+// handles operations.
+func TestMaxGoroutinesDoesNotBlockScheduler(t *testing.T) {
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+
+	beat := New(WithMaxGoroutines(1))
+	beat.Add("* * * * * * *", "TestMaxGoroutinesDoesNotBlockScheduler-1",
+		func(ctx context.Context, userdata any) {
+			select {
+			case started <- struct{}{}:
+			default:
+			}
+			<-release
+		},
+		nil)
+	beat.Start()
+	defer beat.Stop()
+	defer close(release)
+
+	select {
+	case <-time.After(OneSecond):
+		t.Fatal("expected job runs")
+	case <-started:
+	}
+
+	// Let the job fire again while the only permit is held.
+	time.Sleep(OneSecond)
+
+	done := make(chan struct{})
+	go func() {
+		beat.Entries()
+		close(done)
+	}()
+
+	select {
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("expected scheduler is not blocked")
+	case <-done:
+	}
+}
+
+// Stop with a timeout while a job is stuck, expect it returns the context error.
+func TestStopWithContext(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+
+	beat := New()
+	beat.Add("* * * * * * *", "TestStopWithContext-1",
+		func(ctx context.Context, userdata any) {
+			close(started)
+			<-release
+		},
+		nil, WithSkipIfStillRunning())
+	beat.Start()
+
+	select {
+	case <-time.After(OneSecond):
+		t.Fatal("expected job runs")
+	case <-started:
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	if err := beat.StopWithContext(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+	if beat.IsRunning() {
+		t.Fatal("expected beat is stopped")
+	}
+}
+
+// Run a job with RunNow after Stop, expect it runs with a context that is not cancelled.
+func TestRunNowAfterStop(t *testing.T) {
+	errs := make(chan error, 1)
+
+	beat := New(WithMaxGoroutines(1))
+	beat.Add("* 1 1 * 0 0 0", "TestRunNowAfterStop-1",
+		func(ctx context.Context, userdata any) { errs <- ctx.Err() },
+		nil)
+	beat.Start()
+	beat.Stop()
+
+	if err := beat.RunNow("TestRunNowAfterStop-1"); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case err := <-errs:
+		if err != nil {
+			t.Errorf("expected context is not cancelled, got %v", err)
+		}
+	case <-time.After(OneSecond):
+		t.Fatal("expected job runs after Stop")
+	}
+}
+
+// Stop while a job is waiting on its context, expect the context is cancelled.
+func TestStopCancelsJobContext(t *testing.T) {
+	started := make(chan struct{})
+	cancelled := make(chan struct{})
+
+	beat := New()
+	beat.Add("* * * * * * *", "TestStopCancelsJobContext-1",
+		func(ctx context.Context, userdata any) {
+			close(started)
+			<-ctx.Done()
+			close(cancelled)
+		},
+		nil, WithSkipIfStillRunning())
+	beat.Start()
+
+	select {
+	case <-time.After(OneSecond):
+		t.Fatal("expected job runs")
+	case <-started:
+	}
+
+	select {
+	case <-time.After(OneSecond):
+		t.Fatal("expected beat stops")
+	case <-stop(beat):
+	}
+
+	select {
+	case <-cancelled:
+	default:
+		t.Fatal("expected job context is cancelled")
+	}
+
+	// A restarted beat hands out a fresh context.
+	ran := make(chan error, 1)
+	beat.Add("* * * * * * *", "TestStopCancelsJobContext-1",
+		func(ctx context.Context, userdata any) {
+			select {
+			case ran <- ctx.Err():
+			default:
+			}
+		},
+		nil)
+	beat.Start()
+	defer beat.Stop()
+
+	select {
+	case <-time.After(OneSecond):
+		t.Fatal("expected job runs")
+	case err := <-ran:
+		if err != nil {
+			t.Errorf("expected live context, got %v", err)
+		}
+	}
+}
+
+// Add a job with a timeout, expect its context is cancelled when the timeout elapses.
+func TestJobTimeout(t *testing.T) {
+	ch := make(chan error, 1)
+
+	beat := New()
+	beat.Add("* * * * * * *", "TestJobTimeout-1",
+		func(ctx context.Context, userdata any) {
+			select {
+			case <-ctx.Done():
+				ch <- ctx.Err()
+			case <-time.After(OneSecond):
+				ch <- nil
+			}
+		},
+		nil, WithJobTimeout(100*time.Millisecond), WithSkipIfStillRunning())
+	beat.Start()
+	defer beat.Stop()
+
+	select {
+	case <-time.After(2 * OneSecond):
+		t.Fatal("expected job runs")
+	case err := <-ch:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected context.DeadlineExceeded, got %v", err)
+		}
+	}
+}
+
+// Add a job failing twice with retries, expect it succeeds without reporting an error.
+func TestRetry(t *testing.T) {
+	var calls, errs int64
+	done := make(chan struct{})
+
+	beat := New(WithErrorHandler(func(id string, err error) { atomic.AddInt64(&errs, 1) }))
+	beat.AddE("* 1 1 * 0 0 0", "TestRetry-1",
+		func(ctx context.Context, userdata any) error {
+			if atomic.AddInt64(&calls, 1) < 3 {
+				return errors.New("job failed")
+			}
+			close(done)
+			return nil
+		},
+		nil, WithRetry(3, func(attempt int) time.Duration { return 10 * time.Millisecond }))
+
+	beat.RunNow("TestRetry-1")
+
+	select {
+	case <-time.After(OneSecond):
+		t.Fatal("expected job succeeds")
+	case <-done:
+	}
+	beat.Stop()
+
+	if n := atomic.LoadInt64(&calls); n != 3 {
+		t.Errorf("called %d times, expected 3", n)
+	}
+	if n := atomic.LoadInt64(&errs); n != 0 {
+		t.Errorf("reported %d errors, expected 0", n)
+	}
+}
+
+// Add an always failing job with retries, expect the error is reported once retries run out.
+func TestRetryExhausted(t *testing.T) {
+	var calls int64
+	ch := make(chan error, 1)
+
+	beat := New(WithErrorHandler(func(id string, err error) { ch <- err }))
+	beat.AddE("* 1 1 * 0 0 0", "TestRetryExhausted-1",
+		func(ctx context.Context, userdata any) error {
+			atomic.AddInt64(&calls, 1)
+			return errors.New("job failed")
+		},
+		nil, WithRetry(2, nil))
+
+	beat.RunNow("TestRetryExhausted-1")
+
+	select {
+	case <-time.After(OneSecond):
+		t.Fatal("expected error is reported")
+	case <-ch:
+	}
+
+	if n := atomic.LoadInt64(&calls); n != 3 {
+		t.Errorf("called %d times, expected 3", n)
+	}
+}
+
+// Run a panicking job with hooks, expect both hooks are called.
+func TestJobHooks(t *testing.T) {
+	id := "TestJobHooks-1"
+	before := make(chan string, 1)
+	after := make(chan time.Duration, 1)
+
+	beat := New(
+		WithRecovery(),
+		WithBeforeJob(func(jobId string) { before <- jobId }),
+		WithAfterJob(func(jobId string, dur time.Duration) { after <- dur }),
+	)
+	beat.Add("* 1 1 * 0 0 0", id,
+		func(ctx context.Context, userdata any) {
+			time.Sleep(10 * time.Millisecond)
+			panic("panic in beat")
+		},
+		nil)
+
+	beat.RunNow(id)
+
+	select {
+	case <-time.After(OneSecond):
+		t.Fatal("expected before hook is called")
+	case jobId := <-before:
+		if jobId != id {
+			t.Errorf("expected %s, got %s", id, jobId)
+		}
+	}
+
+	select {
+	case <-time.After(OneSecond):
+		t.Fatal("expected after hook is called")
+	case dur := <-after:
+		if dur < 10*time.Millisecond {
+			t.Errorf("expected duration at least 10ms, got %s", dur)
+		}
+	}
+}
+
+// Update a far-future job to every second, expect it runs and keeps its previous run time.
+func TestUpdate(t *testing.T) {
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	id := "TestUpdate-1"
+
+	beat := New()
+	beat.Add("* 1 1 * 0 0 0", id,
+		func(ctx context.Context, userdata any) { userdata.(*sync.WaitGroup).Done() },
+		wg)
+	beat.Start()
+	defer beat.Stop()
+
+	before, _ := beat.Entry(id)
+
+	if err := beat.Update(id, "* * * * * * *"); err != nil {
+		t.Fatal(err)
+	}
+
+	after, _ := beat.Entry(id)
+	if !after.Next.Before(before.Next) || !after.Prev.Equal(before.Prev) {
+		t.Errorf("unexpected entry after update: %+v", after)
+	}
+
+	select {
+	case <-time.After(OneSecond):
+		t.Fatal("expected job runs")
+	case <-wait(wg):
+	}
+
+	if err := beat.Update("TestUpdate-none", "* * * * * * *"); !errors.Is(err, ErrJobNotExist) {
+		t.Errorf("expected ErrJobNotExist, got %v", err)
+	}
+	if err := beat.Update(id, "invalid"); !errors.Is(err, ErrInvalidExp) {
+		t.Errorf("expected ErrInvalidExp, got %v", err)
+	}
+}
+
+// Replace the userdata of a job, expect the next execution receives it.
+func TestSetUserdata(t *testing.T) {
+	id := "TestSetUserdata-1"
+	ch := make(chan any, 1)
+
+	beat := New()
+	beat.Add("* 1 1 * 0 0 0", id,
+		func(ctx context.Context, userdata any) { ch <- userdata },
+		"old")
+	beat.Start()
+	defer beat.Stop()
+
+	if err := beat.SetUserdata(id, "new"); err != nil {
+		t.Fatal(err)
+	}
+	beat.RunNow(id)
+
+	select {
+	case <-time.After(OneSecond):
+		t.Fatal("expected job runs")
+	case data := <-ch:
+		if data != "new" {
+			t.Errorf("expected new userdata, got %v", data)
+		}
+	}
+
+	if err := beat.SetUserdata("TestSetUserdata-none", nil); !errors.Is(err, ErrJobNotExist) {
+		t.Errorf("expected ErrJobNotExist, got %v", err)
+	}
+}
+
+// Count jobs and check membership before and while running.
+func TestCountAndContains(t *testing.T) {
+	id := "TestCountAndContains-1"
+
+	beat := New()
+	if beat.Count() != 0 || beat.Contains(id) {
+		t.Fatal("expected no jobs")
+	}
+
+	beat.Add("* * * * * * *", id, nil, nil)
+	beat.Add("* * * * * * *", "TestCountAndContains-2", nil, nil)
+	if beat.Count() != 2 || !beat.Contains(id) {
+		t.Fatal("expected 2 jobs")
+	}
+
+	beat.Start()
+	defer beat.Stop()
+
+	beat.Remove(id)
+	if beat.Count() != 1 || beat.Contains(id) {
+		t.Fatal("expected 1 job after remove")
+	}
+}
+
+// Add a job without userdata, expect it runs.
+func TestAddFunc(t *testing.T) {
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+
+	beat := New()
+	if err := beat.AddFunc("* 1 1 * 0 0 0", "TestAddFunc-1", func(ctx context.Context) { wg.Done() }); err != nil {
+		t.Fatal(err)
+	}
+	beat.RunNow("TestAddFunc-1")
+
+	select {
+	case <-time.After(OneSecond):
+		t.Fatal("expected job runs")
+	case <-wait(wg):
+	}
+}
+
+// Add and update a job, expect its entry exposes the current expression.
+func TestEntryExpr(t *testing.T) {
+	id := "TestEntryExpr-1"
+
+	beat := New()
+	beat.Add("* 1 1 * 0 0 0", id, nil, nil)
+	if entry, _ := beat.Entry(id); entry.Expr != "* 1 1 * 0 0 0" {
+		t.Errorf("unexpected expr %q", entry.Expr)
+	}
+
+	beat.Update(id, "@hourly")
+	if entry, _ := beat.Entry(id); entry.Expr != "@hourly" {
+		t.Errorf("unexpected expr %q after update", entry.Expr)
+	}
+
+	beat.Start()
+	defer beat.Stop()
+
+	beat.Update(id, "@daily")
+	if entry, _ := beat.Entry(id); entry.Expr != "@daily" {
+		t.Errorf("unexpected expr %q after update while running", entry.Expr)
+	}
+}
+
+// Run a failing and a succeeding job, expect their statistics are tracked.
+func TestStats(t *testing.T) {
+	errJob := errors.New("job failed")
+	wg := &sync.WaitGroup{}
+	wg.Add(2)
+
+	beat := New(WithErrorHandler(func(string, error) {}))
+	beat.AddE("* 1 1 * 0 0 0", "TestStats-1",
+		func(ctx context.Context, userdata any) error { defer wg.Done(); return errJob },
+		nil)
+	beat.Add("* 1 1 * 0 0 0", "TestStats-2",
+		func(ctx context.Context, userdata any) { wg.Done() },
+		nil)
+
+	if _, ok := beat.Stats("TestStats-3"); ok {
+		t.Error("expected no stats for a missing job")
+	}
+	if stats, ok := beat.Stats("TestStats-1"); !ok || stats.Runs != 0 {
+		t.Errorf("expected empty stats, got %+v", stats)
+	}
+
+	beat.Start()
+	defer beat.Stop()
+
+	beat.RunNow("TestStats-1")
+	beat.RunNow("TestStats-2")
+
+	select {
+	case <-time.After(OneSecond):
+		t.Fatal("expected jobs run")
+	case <-wait(wg):
+	}
+
+	// Stats are recorded after the job function returns.
+	deadline := time.Now().Add(OneSecond)
+	for {
+		failed, _ := beat.Stats("TestStats-1")
+		succeeded, _ := beat.Stats("TestStats-2")
+		if failed.Runs == 1 && succeeded.Runs == 1 {
+			if failed.Errors != 1 || !errors.Is(failed.LastError, errJob) || failed.LastRun.IsZero() {
+				t.Errorf("unexpected stats of failed job: %+v", failed)
+			}
+			if succeeded.Successes != 1 || succeeded.LastError != nil {
+				t.Errorf("unexpected stats of succeeded job: %+v", succeeded)
+			}
+			if entry, _ := beat.Entry("TestStats-1"); entry.Stats != failed {
+				t.Errorf("expected entry includes stats %+v, got %+v", failed, entry.Stats)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected stats are recorded, got %+v and %+v", failed, succeeded)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// Schedule jobs with jitter, expect the delay stays within the jitter and before the following slot.
+func TestJitter(t *testing.T) {
+	beat := New(WithJitter(10 * time.Second))
+	now := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.Local)
+
+	minutely, _ := DefaultParser.Parse("* * * * * * 0")
+	secondly, _ := DefaultParser.Parse("* * * * * * *")
+	jobs := []struct {
+		job   *job
+		limit time.Duration
+	}{
+		{&job{Schedule: minutely}, 10 * time.Second},
+		{&job{Schedule: minutely, jitter: 30 * time.Second}, 30 * time.Second},
+		{&job{Schedule: secondly}, time.Second},
+	}
+
+	for _, tc := range jobs {
+		base := tc.job.Schedule.Next(now)
+		for range 100 {
+			beat.scheduleJob(tc.job, now)
+			if d := tc.job.Next.Sub(base); d < 0 || d >= tc.limit {
+				t.Fatalf("expected jitter in [0, %v), got %v", tc.limit, d)
+			}
+		}
+	}
+}
+
+// Start beats with jobs that missed scheduled times, expect the missed runs are caught up.
+func TestCatchUp(t *testing.T) {
+	now := parseTime("2024-11-06T10:00:30+08:00")
+	lastRun := now.Add(-5 * time.Minute)
+
+	tests := []struct {
+		opts     []option
+		lastRun  time.Time
+		expected int64
+	}{
+		{nil, lastRun, 0},
+		{[]option{WithCatchUp()}, time.Time{}, 0},
+		{[]option{WithCatchUp()}, now.Add(-time.Second), 0},
+		{[]option{WithCatchUp()}, lastRun, 1},
+		{[]option{WithCatchUpLimit(3)}, lastRun, 3},
+		{[]option{WithCatchUpLimit(10)}, lastRun, 5},
+	}
+
+	for _, tc := range tests {
+		var calls int64
+		beat := New(append(tc.opts, WithClock(newFakeClock(now)))...)
+		beat.Add("* * * * * * 0", "TestCatchUp-1",
+			func(ctx context.Context, userdata any) { atomic.AddInt64(&calls, 1) },
+			nil, WithLastRun(tc.lastRun))
+
+		beat.Start()
+		// Catch-up runs happen before the first request is handled.
+		beat.Count()
+		beat.Stop()
+
+		if n := atomic.LoadInt64(&calls); n != tc.expected {
+			t.Errorf("last run %v: called %d times, expected %d", tc.lastRun, n, tc.expected)
+		}
+	}
+}
+
+// Raise and remove the goroutine limit while running, expect later jobs are no longer blocked.
+func TestSetMaxGoroutines(t *testing.T) {
+	started := make(chan string, 3)
+	release := make(chan struct{})
+	fn := func(ctx context.Context, userdata any) {
+		started <- userdata.(string)
+		<-release
+	}
+
+	beat := New(WithMaxGoroutines(1))
+	beat.Add("* 1 1 * 0 0 0", "TestSetMaxGoroutines-1", fn, "1")
+	beat.Add("* 1 1 * 0 0 0", "TestSetMaxGoroutines-2", fn, "2")
+	beat.Add("* 1 1 * 0 0 0", "TestSetMaxGoroutines-3", fn, "3")
+	beat.Start()
+	defer func() {
+		close(release)
+		beat.Stop()
+	}()
+
+	beat.RunNow("TestSetMaxGoroutines-1")
+	<-started
+
+	beat.SetMaxGoroutines(2)
+	beat.RunNow("TestSetMaxGoroutines-2")
+	select {
+	case <-started:
+	case <-time.After(OneSecond):
+		t.Fatal("expected job starts after raising the limit")
+	}
+
+	beat.SetMaxGoroutines(0)
+	beat.RunNow("TestSetMaxGoroutines-3")
+	select {
+	case <-started:
+	case <-time.After(OneSecond):
+		t.Fatal("expected job starts after removing the limit")
+	}
+}
+
+// Run weighted jobs within a goroutine limit, expect heavy jobs wait for enough permits.
+func TestWeight(t *testing.T) {
+	started := make(chan string, 3)
+	release := make(chan struct{})
+	fn := func(ctx context.Context, userdata any) {
+		started <- userdata.(string)
+		<-release
+	}
+
+	beat := New(WithMaxGoroutines(3))
+	if err := beat.Add("* 1 1 * 0 0 0", "TestWeight-0", fn, "0", WithWeight(4)); !errors.Is(err, ErrInvalidWeight) {
+		t.Errorf("expected %v, got %v", ErrInvalidWeight, err)
+	}
+	if err := beat.AddBatch([]JobSpec{
+		{Expr: "* 1 1 * 0 0 0", Id: "TestWeight-0"},
+		{Expr: "* 1 1 * 0 0 0", Id: "TestWeight-00", Options: []JobOption{WithWeight(4)}},
+	}); !errors.Is(err, ErrInvalidWeight) || beat.Count() != 0 {
+		t.Errorf("expected %v without adding any job, got %v", ErrInvalidWeight, err)
+	}
+
+	beat.Add("* 1 1 * 0 0 0", "TestWeight-1", fn, "light")
+	beat.Add("* 1 1 * 0 0 0", "TestWeight-2", fn, "heavy", WithWeight(3))
+	beat.Start()
+	defer beat.Stop()
+
+	beat.RunNow("TestWeight-1")
+	<-started
+	beat.RunNow("TestWeight-2")
+
+	select {
+	case id := <-started:
+		t.Fatalf("expected %s job waits for enough permits", id)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	release <- struct{}{}
+	select {
+	case <-started:
+	case <-time.After(OneSecond):
+		t.Fatal("expected heavy job starts after the light job ends")
+	}
+
+	// The weight is capped by a lowered limit instead of waiting forever.
+	beat.SetMaxGoroutines(1)
+	release <- struct{}{}
+	beat.RunNow("TestWeight-2")
+	select {
+	case <-started:
+	case <-time.After(OneSecond):
+		t.Fatal("expected heavy job starts after lowering the limit")
+	}
+	close(release)
+}
+
+// Run jobs due at once with a rate limit, expect their starts are spread over time.
+func TestRateLimit(t *testing.T) {
+	started := make(chan time.Time, 3)
+	fn := func(ctx context.Context, userdata any) { started <- time.Now() }
+
+	beat := New(WithRateLimit(rate.Every(100*time.Millisecond), 1))
+	beat.Add("* 1 1 * 0 0 0", "TestRateLimit-1", fn, nil)
+	beat.Add("* 1 1 * 0 0 0", "TestRateLimit-2", fn, nil)
+	beat.Add("* 1 1 * 0 0 0", "TestRateLimit-3", fn, nil)
+	beat.Start()
+	defer beat.Stop()
+
+	begin := time.Now()
+	beat.RunNow("TestRateLimit-1")
+	beat.RunNow("TestRateLimit-2")
+	beat.RunNow("TestRateLimit-3")
+
+	var last time.Time
+	for range 3 {
+		select {
+		case last = <-started:
+		case <-time.After(OneSecond):
+			t.Fatal("expected jobs run")
+		}
+	}
+	if elapsed := last.Sub(begin); elapsed < 150*time.Millisecond {
+		t.Errorf("expected starts are limited, all started in %v", elapsed)
+	}
+}
+
+// Reset a stopped beat, expect stats and run times are cleared while jobs are kept.
+func TestReset(t *testing.T) {
+	ran := make(chan struct{}, 2)
+
+	beat := New()
+	beat.Add("* 1 1 * 0 0 0", "TestReset-1", func(ctx context.Context, userdata any) {
+		ran <- struct{}{}
+	}, nil, WithLastRun(time.Now().Add(-time.Hour)))
+	beat.Start()
+
+	if err := beat.Reset(); !errors.Is(err, ErrRunning) {
+		t.Errorf("expected %v, got %v", ErrRunning, err)
+	}
+
+	beat.RunNow("TestReset-1")
+	<-ran
+	beat.Stop()
+
+	if err := beat.Reset(); err != nil {
+		t.Fatal(err)
+	}
+
+	entry, ok := beat.Entry("TestReset-1")
+	if !ok || !entry.Next.IsZero() || !entry.Prev.IsZero() {
+		t.Errorf("expected job is kept without run times, got %+v", entry)
+	}
+	if stats, _ := beat.Stats("TestReset-1"); stats.Runs != 0 {
+		t.Errorf("expected stats are cleared, got %+v", stats)
+	}
+	if metrics := beat.Metrics(); metrics.Executions != 0 {
+		t.Errorf("expected metrics are cleared, got %+v", metrics)
+	}
+
+	beat.Start()
+	defer beat.Stop()
+
+	beat.RunNow("TestReset-1")
+	select {
+	case <-ran:
+	case <-time.After(OneSecond):
+		t.Fatal("expected job runs after reset")
+	}
+}
+
+// Start and run beats with a context, expect they stop when the context is cancelled.
+func TestStartContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	beat := New()
+	beat.StartContext(ctx)
+	if !beat.IsRunning() {
+		t.Fatal("expected beat is running")
+	}
+	cancel()
+
+	deadline := time.Now().Add(OneSecond)
+	for beat.IsRunning() {
+		if time.Now().After(deadline) {
+			t.Fatal("expected beat stops when the context is cancelled")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	ctx, cancel = context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		beat.RunContext(ctx)
+		close(done)
+	}()
+	time.AfterFunc(100*time.Millisecond, cancel)
+
+	select {
+	case <-done:
+	case <-time.After(OneSecond):
+		t.Fatal("expected RunContext returns when the context is cancelled")
+	}
+	if beat.IsRunning() {
+		t.Error("expected beat is stopped")
+	}
+}
+
+// Panic in a job with a panic handler, expect the handler receives the id, value and stack.
+func TestPanicHandler(t *testing.T) {
+	type panicked struct {
+		id        string
+		recovered any
+		stack     []byte
+	}
+	ch := make(chan panicked, 1)
+
+	beat := New(WithRecovery(), WithPanicHandler(func(id string, recovered any, stack []byte) {
+		ch <- panicked{id, recovered, stack}
+	}))
+	beat.Add("* 1 1 * 0 0 0", "TestPanicHandler-1", func(ctx context.Context, userdata any) {
+		panic("panic in beat")
+	}, nil)
+	beat.RunNow("TestPanicHandler-1")
+
+	select {
+	case p := <-ch:
+		if p.id != "TestPanicHandler-1" || p.recovered != "panic in beat" {
+			t.Errorf("unexpected panic %q: %v", p.id, p.recovered)
+		}
+		if !bytes.Contains(p.stack, []byte("TestPanicHandler")) {
+			t.Errorf("expected stack contains the job, got %s", p.stack)
+		}
+	case <-time.After(OneSecond):
+		t.Fatal("expected panic handler is called")
+	}
+}
+
+// Capture stacks with and without a limit, expect the buffer grows unless limited.
+func TestStack(t *testing.T) {
+	var deep func(n int) []byte
+	deep = func(n int) []byte {
+		if n == 0 {
+			return stack(0)
+		}
+		return deep(n - 1)
+	}
+
+	if full := deep(1000); len(full) <= initialStackSize || !bytes.Contains(full, []byte("TestStack")) {
+		t.Errorf("expected the full stack outgrows the initial buffer, got %d bytes", len(full))
+	}
+	if limited := stack(100); len(limited) != 100 {
+		t.Errorf("expected the stack is limited to 100 bytes, got %d", len(limited))
+	}
+	if shallow := stack(0); len(shallow) >= initialStackSize {
+		t.Errorf("expected a shallow stack fits the initial buffer, got %d bytes", len(shallow))
+	}
+}
+
+// Tag jobs, query and remove them by tag before and while running.
+func TestTags(t *testing.T) {
+	beat := New()
+	beat.Add("* * * * * * *", "TestTags-1", nil, nil, WithTags("tenant-a", "report"))
+	beat.Add("* * * * * * *", "TestTags-2", nil, nil, WithTags("tenant-a"))
+	beat.Add("* * * * * * *", "TestTags-3", nil, nil, WithTags("tenant-b", "report"))
+	beat.Add("* * * * * * *", "TestTags-4", nil, nil)
+
+	if entries := beat.EntriesByTag("tenant-a"); len(entries) != 2 {
+		t.Errorf("expected 2 jobs tagged tenant-a, got %d", len(entries))
+	}
+	if entry, _ := beat.Entry("TestTags-1"); !slices.Equal(entry.Tags, []string{"tenant-a", "report"}) {
+		t.Errorf("unexpected tags %v", entry.Tags)
+	}
+
+	beat.RemoveByTag("tenant-a")
+	if beat.Count() != 2 || beat.Contains("TestTags-1") || beat.Contains("TestTags-2") {
+		t.Errorf("expected jobs tagged tenant-a are removed, got %v", beat.Entries())
+	}
+
+	beat.Start()
+	defer beat.Stop()
+
+	if entries := beat.EntriesByTag("report"); len(entries) != 1 || entries[0].Id != "TestTags-3" {
+		t.Errorf("expected only TestTags-3 is tagged report, got %v", entries)
+	}
+
+	beat.RemoveByTag("report")
+	if beat.Count() != 1 || !beat.Contains("TestTags-4") {
+		t.Errorf("expected only the untagged job remains, got %v", beat.Entries())
+	}
+}
+
+// Add a job with several expressions, expect invalid ones are rejected.
+func TestAddMulti(t *testing.T) {
+	beat := New()
+
+	if err := beat.AddMulti(nil, "TestAddMulti-1", nil, nil); !errors.Is(err, ErrInvalidExp) {
+		t.Errorf("expected ErrInvalidExp, got %v", err)
+	}
+	if err := beat.AddMulti([]string{"@daily", "invalid"}, "TestAddMulti-1", nil, nil); !errors.Is(err, ErrInvalidExp) {
+		t.Errorf("expected ErrInvalidExp, got %v", err)
+	}
+	if beat.Contains("TestAddMulti-1") {
+		t.Fatal("expected invalid job is not added")
+	}
+
+	if err := beat.AddMulti([]string{"* * * 1-5 9 0 0", "* * * 0 12 0 0"}, "TestAddMulti-1", nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	entry, _ := beat.Entry("TestAddMulti-1")
+	if _, ok := entry.Schedule.(unionSchedule); !ok || len(entry.Schedule.(unionSchedule)) != 2 {
+		t.Errorf("expected a union of 2 schedules, got %#v", entry.Schedule)
+	}
+}
+
+// Schedule jobs with blackouts, expect blacked out times are skipped and the search is capped.
+func TestBlackout(t *testing.T) {
+	beat := New()
+	now := parseTime("2024-11-09T23:30:00+08:00") // Saturday
+
+	hourly, _ := DefaultParser.Parse("@hourly")
+	maintenance := func(t time.Time) bool {
+		return t.Weekday() == time.Sunday && t.Hour() < 2
+	}
+
+	scheduled := &job{Schedule: hourly}
+	WithBlackout(maintenance)(scheduled)
+	beat.scheduleJob(scheduled, now)
+	if expected := parseTime("2024-11-10T02:00:00+08:00"); !scheduled.Next.Equal(expected) {
+		t.Errorf("(expected) %s != %s (actual)", expected, scheduled.Next)
+	}
+
+	never := &job{Schedule: Every(time.Second)}
+	WithBlackout(func(time.Time) bool { return true })(never)
+	beat.scheduleJob(never, now)
+	if !never.Next.IsZero() {
+		t.Errorf("expected a job blacked out forever never runs, got %s", never.Next)
+	}
+}
+
+// Queue requests while the scheduler is blocked, expect callers do not block and requests are handled in order.
+func TestOperateBuffer(t *testing.T) {
+	entered := make(chan struct{})
+	release := make(chan struct{})
+
+	beat := New(WithOperateBuffer(4))
+	beat.Add("* * * * * * *", "TestOperateBuffer-1", nil, nil, WithGuard(func(context.Context, any) bool {
+		select {
+		case entered <- struct{}{}:
+			<-release
+		default:
+		}
+		return false
+	}))
+	beat.Add("* * * * * * *", "TestOperateBuffer-2", nil, nil)
+	beat.Add("* * * * * * *", "TestOperateBuffer-3", nil, nil)
+	beat.Start()
+	defer beat.Stop()
+
+	select {
+	case <-entered:
+	case <-time.After(2 * OneSecond):
+		t.Fatal("expected scheduler enters the guard")
+	}
+
+	queued := make(chan struct{})
+	go func() {
+		beat.Remove("TestOperateBuffer-2")
+		beat.PauseAll()
+		beat.RemoveByTag("none")
+		close(queued)
+	}()
+
+	select {
+	case <-queued:
+	case <-time.After(OneSecond):
+		t.Fatal("expected requests are queued without blocking")
+	}
+
+	close(release)
+
+	entries := beat.Entries()
+	if len(entries) != 2 || beat.Contains("TestOperateBuffer-2") {
+		t.Errorf("expected job is removed before the snapshot, got %v", entries)
+	}
+	for _, entry := range entries {
+		if !entry.Next.IsZero() {
+			t.Errorf("expected job %s is paused before the snapshot", entry.Id)
+		}
+	}
+}
+
+// Stop with drain while a job is due but not fired yet, expect it runs once with a live context.
+func TestStopAndDrain(t *testing.T) {
+	start := parseTime("2024-11-06T10:20:30+08:00")
+	clock := newFakeClock(start)
+	var runs atomic.Int32
+	var ctxErr atomic.Value
+
+	beat := New(WithClock(clock), WithLocation(start.Location()))
+	beat.Add("* * * * * * */10", "TestStopAndDrain-1", func(ctx context.Context, userdata any) {
+		runs.Add(1)
+		time.Sleep(10 * time.Millisecond)
+		ctxErr.Store(fmt.Sprint(ctx.Err()))
+	}, nil)
+	beat.Add("* 1 1 * 0 0 0", "TestStopAndDrain-2", func(ctx context.Context, userdata any) {
+		t.Error("expected job not due is not run")
+	}, nil)
+	beat.Start()
+
+	clock.BlockUntil(1)
+	// Move the clock past the next run time without firing the timer.
+	clock.lock.Lock()
+	clock.now = clock.now.Add(time.Hour)
+	clock.lock.Unlock()
+
+	beat.StopAndDrain()
+
+	if runs.Load() != 1 {
+		t.Errorf("expected due job runs once, got %d", runs.Load())
+	}
+	if err := ctxErr.Load(); err != "<nil>" {
+		t.Errorf("expected context is not canceled while draining, got %v", err)
+	}
+	if beat.IsRunning() {
+		t.Error("expected beat is stopped")
+	}
+}
+
+// Pop jobs due at the same time, expect they are ordered by priority and then by the order added.
+func TestPriority(t *testing.T) {
+	beat := New()
+	beat.Add("* * * * * * *", "TestPriority-1", nil, nil)
+	beat.Add("* * * * * * *", "TestPriority-2", nil, nil, WithPriority(-1))
+	beat.Add("* * * * * * *", "TestPriority-3", nil, nil)
+	beat.Add("* * * * * * *", "TestPriority-4", nil, nil, WithPriority(10))
+	beat.Add("* * * * * * *", "TestPriority-5", nil, nil)
+
+	now := time.Now()
+	for _, job := range beat.jobs {
+		job.Next = now
+	}
+	heap.Init(&beat.jobs)
+
+	var ids []string
+	for _, job := range beat.popDueJobs(now) {
+		ids = append(ids, job.Id)
+	}
+
+	expected := []string{"TestPriority-4", "TestPriority-1", "TestPriority-3", "TestPriority-5", "TestPriority-2"}
+	if !slices.Equal(ids, expected) {
+		t.Errorf("expected %v, got %v", expected, ids)
+	}
+}
+
+// Add and update jobs with a minimum interval, expect too frequent schedules are rejected.
+func TestMinInterval(t *testing.T) {
+	beat := New(WithMinInterval(time.Minute))
+
+	if err := beat.Add("@every 1s", "TestMinInterval-1", nil, nil); !errors.Is(err, ErrTooFrequent) {
+		t.Errorf("expected %v, got %v", ErrTooFrequent, err)
+	}
+	if err := beat.AddSchedule(Every(time.Second), "TestMinInterval-1", nil, nil); !errors.Is(err, ErrTooFrequent) {
+		t.Errorf("expected %v, got %v", ErrTooFrequent, err)
+	}
+	if err := beat.AddBatch([]JobSpec{{Expr: "* * * * * * *", Id: "TestMinInterval-1"}}); !errors.Is(err, ErrTooFrequent) {
+		t.Errorf("expected %v, got %v", ErrTooFrequent, err)
+	}
+	if beat.Count() != 0 {
+		t.Errorf("expected no job is added, got %d", beat.Count())
+	}
+
+	if err := beat.Add("@every 1m", "TestMinInterval-2", nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := beat.Add("@yearly", "TestMinInterval-3", nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := beat.AddSchedule(At(time.Now().Add(time.Hour)), "TestMinInterval-4", nil, nil); err != nil {
+		t.Fatalf("expected a single run is allowed, got %v", err)
+	}
+
+	if err := beat.Update("TestMinInterval-2", "* * * * * * */30"); !errors.Is(err, ErrTooFrequent) {
+		t.Errorf("expected %v, got %v", ErrTooFrequent, err)
+	}
+	if entry, _ := beat.Entry("TestMinInterval-2"); entry.Expr != "@every 1m" {
+		t.Errorf("expected job is not updated, got %s", entry.Expr)
+	}
+}
+
+// Queue a removal and sync, expect the removal has been applied once Sync returns.
+func TestSync(t *testing.T) {
+	beat := New(WithOperateBuffer(4))
+	beat.Sync()
+
+	beat.Add("* 1 1 * 0 0 0", "TestSync-1", nil, nil)
+	events := beat.Events()
+	beat.Start()
+	defer beat.Stop()
+
+	beat.Remove("TestSync-1")
+	beat.Sync()
+
+	select {
+	case event := <-events:
+		if event.Type != EventRemoved || event.JobId != "TestSync-1" {
+			t.Errorf("unexpected event %+v", event)
+		}
+	default:
+		t.Fatal("expected removal is applied when Sync returns")
+	}
+}
+
+type optionsKey struct{}
+
+// Create a beat with the canonical options, expect each of them is applied.
+func TestOptions(t *testing.T) {
+	loc, err := time.LoadLocation("Pacific/Majuro")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.WithValue(context.Background(), optionsKey{}, "value")
+
+	beat := New(
+		WithLocation(loc),
+		WithContext(ctx),
+		WithMaxGoroutines(2),
+		WithoutRecovery(),
+		WithRecovery(),
+		WithLogger(NopLogger{}),
+	)
+
+	if beat.Location() != loc {
+		t.Errorf("expected location %v, got %v", loc, beat.Location())
+	}
+	if beat.MaxGoroutines() != 2 {
+		t.Errorf("expected max goroutines 2, got %d", beat.MaxGoroutines())
+	}
+	if !beat.withRecovery {
+		t.Error("expected recovery is enabled")
+	}
+	if _, ok := beat.log.(NopLogger); !ok {
+		t.Errorf("expected NopLogger, got %T", beat.log)
+	}
+
+	got := make(chan any, 1)
+	beat.Add("* * * * * * *", "TestOptions-1",
+		func(ctx context.Context, userdata any) { got <- ctx.Value(optionsKey{}) },
+		nil)
+	if err := beat.RunNow("TestOptions-1"); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case v := <-got:
+		if v != "value" {
+			t.Errorf("expected job context derived from WithContext, got value %v", v)
+		}
+	case <-time.After(OneSecond):
+		t.Fatal("expected job runs")
+	}
+}
+
+// Create a beat with a nil location and a negative max goroutines, expect the defaults are used.
+func TestOptionsDefaults(t *testing.T) {
+	beat := New(WithLocation(nil), WithMaxGoroutines(-1))
+
+	if beat.Location() != time.Local {
+		t.Errorf("expected location %v, got %v", time.Local, beat.Location())
+	}
+	if beat.MaxGoroutines() != 0 {
+		t.Errorf("expected max goroutines 0, got %d", beat.MaxGoroutines())
+	}
+	times, err := beat.NextN("* * * * * * *", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if times[0].Location() != time.Local {
+		t.Errorf("expected next time in %v, got %v", time.Local, times[0].Location())
+	}
+}
+
+// Pass nil to each option, expect the defaults are used and the beat runs jobs without panicking.
+func TestNilOptions(t *testing.T) {
+	tests := []struct {
+		name string
+		opt  option
+	}{
+		{"WithParser", WithParser(nil)},
+		{"WithLocation", WithLocation(nil)},
+		{"WithClock", WithClock(nil)},
+		{"WithNowFunc", WithNowFunc(nil)},
+		{"WithLogger", WithLogger(nil)},
+		{"WithContext", WithContext(nil)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			beat := New(tt.opt)
+
+			if beat.parser != DefaultParser {
+				t.Errorf("expected DefaultParser, got %v", beat.parser)
+			}
+			if beat.location != time.Local {
+				t.Errorf("expected location %v, got %v", time.Local, beat.location)
+			}
+			if beat.clock == nil || beat.log == nil || beat.ctx == nil {
+				t.Fatal("expected nil clock, logger and context are replaced")
+			}
+
+			done := make(chan struct{})
+			if err := beat.Add("* * * * * * *", "TestNilOptions-1",
+				func(ctx context.Context, userdata any) { close(done) },
+				nil); err != nil {
+				t.Fatal(err)
+			}
+
+			beat.Start()
+			defer beat.Stop()
+
+			select {
+			case <-done:
+			case <-time.After(OneSecond * 2):
+				t.Fatal("expected job runs")
+			}
+		})
+	}
+}
+
+// Add several jobs sharing the same next time, expect each runs exactly once per wake.
+func TestSameNextTime(t *testing.T) {
+	start := parseTime("2024-11-06T10:20:30+08:00")
+	clock := newFakeClock(start)
+
+	var lock sync.Mutex
+	calls := map[string]int{}
+	wg := &sync.WaitGroup{}
+	wg.Add(3)
+
+	fn := func(ctx context.Context, userdata any) {
+		lock.Lock()
+		calls[userdata.(string)]++
+		lock.Unlock()
+		wg.Done()
+	}
+
+	beat := New(WithClock(clock), WithLocation(start.Location()))
+	for _, id := range []string{"TestSameNextTime-1", "TestSameNextTime-2", "TestSameNextTime-3"} {
+		beat.Add("* * * * * * 31", id, fn, id)
+	}
+	beat.Start()
+	defer beat.Stop()
+
+	clock.BlockUntil(1)
+	clock.Advance(time.Second)
+
+	select {
+	case <-time.After(OneSecond):
+		t.Fatal("expected all jobs run")
+	case <-wait(wg):
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+	for id, n := range calls {
+		if n != 1 {
+			t.Errorf("job %s called %d times, expected 1", id, n)
+		}
+	}
+
+	expected := parseTime("2024-11-06T10:21:31+08:00")
+	for _, entry := range beat.Entries() {
+		if !entry.Next.Equal(expected) || !entry.Prev.Equal(start.Add(time.Second)) {
+			t.Errorf("job %s rescheduled to %s (prev %s), expected %s", entry.Id, entry.Next, entry.Prev, expected)
+		}
+	}
+}
+
+// Pause a job, expect it does not fire until resumed.
+func TestPauseAndResume(t *testing.T) {
+	start := parseTime("2024-11-06T10:20:30+08:00")
+	clock := newFakeClock(start)
+	fired := make(chan struct{}, 1)
+	id := "TestPauseAndResume-1"
+
+	beat := New(WithClock(clock), WithLocation(start.Location()))
+	beat.Add("* * * * * * *", id,
+		func(ctx context.Context, userdata any) { fired <- struct{}{} },
+		nil)
+	beat.Start()
+	defer beat.Stop()
+
+	if paused, ok := beat.IsPaused(id); paused || !ok {
+		t.Errorf("expected existing job is not paused, got %v %v", paused, ok)
+	}
+
+	if err := beat.Pause(id); err != nil {
+		t.Fatal(err)
+	}
+	if err := beat.Pause(id); err != nil {
+		t.Fatalf("expected pausing a paused job is a no-op, got %v", err)
+	}
+	if entry, _ := beat.Entry(id); !entry.Next.IsZero() || !entry.Paused {
+		t.Errorf("expected paused job is unscheduled, got %s", entry.Next)
+	}
+	if paused, ok := beat.IsPaused(id); !paused || !ok {
+		t.Errorf("expected job is paused, got %v %v", paused, ok)
+	}
+
+	clock.BlockUntil(1)
+	clock.Advance(5 * time.Second)
+
+	select {
+	case <-fired:
+		t.Fatal("expected paused job does not fire")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	if err := beat.Resume(id); err != nil {
+		t.Fatal(err)
+	}
+	if entry, _ := beat.Entry(id); !entry.Next.Equal(start.Add(6*time.Second)) || entry.Paused {
+		t.Errorf("expected resumed job is rescheduled from now, got %s", entry.Next)
+	}
+
+	clock.BlockUntil(1)
+	clock.Advance(time.Second)
+
+	select {
+	case <-fired:
+	case <-time.After(OneSecond):
+		t.Fatal("expected resumed job fires")
+	}
+
+	if err := beat.Pause("TestPauseAndResume-none"); !errors.Is(err, ErrJobNotExist) {
+		t.Errorf("expected ErrJobNotExist, got %v", err)
+	}
+	if paused, ok := beat.IsPaused("TestPauseAndResume-none"); paused || ok {
+		t.Errorf("expected missing job, got %v %v", paused, ok)
+	}
+}
+
+// Pause all jobs, expect none fires until all are resumed.
+func TestPauseAllAndResumeAll(t *testing.T) {
+	start := parseTime("2024-11-06T10:20:30+08:00")
+	clock := newFakeClock(start)
+	fired := make(chan string, 2)
+
+	fn := func(ctx context.Context, userdata any) { fired <- userdata.(string) }
+
+	beat := New(WithClock(clock), WithLocation(start.Location()))
+	beat.Add("* * * * * * *", "TestPauseAllAndResumeAll-1", fn, "1")
+	beat.Add("* * * * * * *", "TestPauseAllAndResumeAll-2", fn, "2")
+	beat.Start()
+	defer beat.Stop()
+
+	beat.PauseAll()
+	beat.PauseAll()
+	for _, entry := range beat.Entries() {
+		if !entry.Next.IsZero() {
+			t.Errorf("expected job %s is paused", entry.Id)
+		}
+	}
+
+	clock.BlockUntil(1)
+	clock.Advance(5 * time.Second)
+
+	select {
+	case id := <-fired:
+		t.Fatalf("expected paused job %s does not fire", id)
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	beat.ResumeAll()
+	beat.ResumeAll()
+
+	clock.BlockUntil(1)
+	clock.Advance(time.Second)
+
+	for range 2 {
+		select {
+		case <-fired:
+		case <-time.After(OneSecond):
+			t.Fatal("expected resumed jobs fire")
+		}
+	}
+}
+
+// listSchedule fires at the given times only.
+type listSchedule []time.Time
+
+func (s listSchedule) Next(t time.Time) time.Time {
+	for _, next := range s {
+		if next.After(t) {
+			return next
+		}
+	}
+	return time.Time{}
+}
+
+// Add jobs with a custom schedule before and while running, expect they fire at its times.
+func TestAddCustomSchedule(t *testing.T) {
+	start := parseTime("2024-11-06T10:20:30+08:00")
+	clock := newFakeClock(start)
+	fired := make(chan string, 2)
+	sched := listSchedule{start.Add(time.Minute), start.Add(3 * time.Minute)}
+	fn := func(ctx context.Context, userdata any) { fired <- userdata.(string) }
+
+	beat := New(WithClock(clock), WithLocation(start.Location()))
+	if err := beat.AddSchedule(nil, "TestAddCustomSchedule-0", fn, nil); !errors.Is(err, ErrNilSchedule) {
+		t.Errorf("expected ErrNilSchedule, got %v", err)
+	}
+	beat.AddSchedule(sched, "TestAddCustomSchedule-1", fn, "1")
+	beat.Start()
+	defer beat.Stop()
+	beat.AddSchedule(sched, "TestAddCustomSchedule-2", fn, "2")
+
+	clock.BlockUntil(1)
+	clock.Advance(time.Minute)
+	for range 2 {
+		select {
+		case <-fired:
+		case <-time.After(OneSecond):
+			t.Fatal("expected both jobs fire")
+		}
+	}
+
+	entry, _ := beat.Entry("TestAddCustomSchedule-1")
+	if expected := start.Add(3 * time.Minute); !entry.Next.Equal(expected) {
+		t.Errorf("(expected) %s != %s (actual)", expected, entry.Next)
+	}
+}
+
+// Change the location while running, expect next times are recomputed in the new location.
+func TestSetLocation(t *testing.T) {
+	start := parseTime("2024-11-06T08:30:00Z")
+	shanghai, err := time.LoadLocation("Asia/Shanghai")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	beat := New(WithClock(newFakeClock(start)), WithLocation(time.UTC))
+	beat.Add("* * * * 9 0 0", "TestSetLocation-1", nil, nil)
+	beat.Start()
+	defer beat.Stop()
+
+	entry, _ := beat.Entry("TestSetLocation-1")
+	if expected := parseTime("2024-11-06T09:00:00Z"); !entry.Next.Equal(expected) {
+		t.Errorf("(expected) %s != %s (actual)", expected, entry.Next)
+	}
+
+	beat.SetLocation(shanghai)
+	if beat.Location() != shanghai {
+		t.Errorf("expected location %s, got %s", shanghai, beat.Location())
+	}
+
+	entry, _ = beat.Entry("TestSetLocation-1")
+	if expected := parseTime("2024-11-07T09:00:00+08:00"); !entry.Next.Equal(expected) {
+		t.Errorf("(expected) %s != %s (actual)", expected, entry.Next)
+	}
+
+	beat.SetLocation(nil)
+	if beat.Location() != time.Local {
+		t.Errorf("expected local location, got %s", beat.Location())
+	}
+}
+
+// Query the time until the next run, expect missing and paused jobs report false.
+func TestTimeUntilNext(t *testing.T) {
+	start := parseTime("2024-11-06T10:20:30+08:00")
+	clock := newFakeClock(start)
+
+	beat := New(WithClock(clock), WithLocation(start.Location()))
+	beat.Add("* * * * * 21 0", "TestTimeUntilNext-1", nil, nil)
+	beat.Add("* * * * * 21 0", "TestTimeUntilNext-2", nil, nil)
+	beat.Pause("TestTimeUntilNext-2")
+	beat.Start()
+	defer beat.Stop()
+
+	if d, ok := beat.TimeUntilNext("TestTimeUntilNext-1"); !ok || d != 30*time.Second {
+		t.Errorf("expected 30s, got %v %v", d, ok)
+	}
+	if _, ok := beat.TimeUntilNext("TestTimeUntilNext-2"); ok {
+		t.Error("expected paused job has no next run")
+	}
+	if _, ok := beat.TimeUntilNext("unknown"); ok {
+		t.Error("expected unknown job has no next run")
+	}
+
+	// Moving the clock without waking the scheduler makes the job overdue.
+	clock.lock.Lock()
+	clock.now = clock.now.Add(time.Minute)
+	clock.lock.Unlock()
+	if d, ok := beat.TimeUntilNext("TestTimeUntilNext-1"); !ok || d != -30*time.Second {
+		t.Errorf("expected -30s, got %v %v", d, ok)
+	}
+}
+
+// Reschedule a job from within itself, expect the new interval is used from then on.
+func TestReschedule(t *testing.T) {
+	start := parseTime("2024-11-06T10:20:30+08:00")
+	clock := newFakeClock(start)
+	fired := make(chan time.Time, 1)
+
+	var beat *Beat
+	beat = New(WithClock(clock), WithLocation(start.Location()))
+	beat.AddSchedule(Every(time.Minute), "TestReschedule-1",
+		func(ctx context.Context, userdata any) {
+			if err := beat.Reschedule("TestReschedule-1", Every(2*time.Minute)); err != nil {
+				t.Error(err)
+			}
+			fired <- clock.Now()
+		},
+		nil)
+	beat.Start()
+	defer beat.Stop()
+
+	if err := beat.Reschedule("unknown", Every(time.Minute)); !errors.Is(err, ErrJobNotExist) {
+		t.Errorf("expected ErrJobNotExist, got %v", err)
+	}
+	if err := beat.Reschedule("TestReschedule-1", nil); !errors.Is(err, ErrNilSchedule) {
+		t.Errorf("expected ErrNilSchedule, got %v", err)
+	}
+
+	clock.BlockUntil(1)
+	clock.Advance(time.Minute)
+	select {
+	case <-fired:
+	case <-time.After(OneSecond):
+		t.Fatal("expected job fires")
+	}
+
+	entry, _ := beat.Entry("TestReschedule-1")
+	if expected := start.Add(3 * time.Minute); !entry.Next.Equal(expected) {
+		t.Errorf("(expected) %s != %s (actual)", expected, entry.Next)
+	}
+}
+
+// Add a guarded job, expect fires are skipped while the guard is false but the schedule advances.
+func TestGuard(t *testing.T) {
+	start := parseTime("2024-11-06T10:20:30+08:00")
+	clock := newFakeClock(start)
+	fired := make(chan time.Time, 1)
+
+	var enabled atomic.Bool
+	beat := New(WithClock(clock), WithLocation(start.Location()))
+	beat.AddSchedule(Every(time.Minute), "TestGuard-1",
+		func(ctx context.Context, userdata any) { fired <- clock.Now() },
+		"flag", WithGuard(func(ctx context.Context, userdata any) bool {
+			return userdata == "flag" && enabled.Load()
+		}))
+	beat.Start()
+	defer beat.Stop()
+
+	clock.BlockUntil(1)
+	clock.Advance(time.Minute)
+	clock.BlockUntil(1)
+
+	select {
+	case tm := <-fired:
+		t.Fatalf("expected guarded job is skipped, fired at %s", tm)
+	case <-time.After(10 * time.Millisecond):
+	}
+	if entry, _ := beat.Entry("TestGuard-1"); !entry.Next.Equal(start.Add(2 * time.Minute)) {
+		t.Errorf("expected the schedule advances, next is %s", entry.Next)
+	}
+
+	enabled.Store(true)
+	clock.Advance(time.Minute)
+
+	select {
+	case tm := <-fired:
+		if expected := start.Add(2 * time.Minute); !tm.Equal(expected) {
+			t.Errorf("(expected) %s != %s (actual)", expected, tm)
+		}
+	case <-time.After(OneSecond):
+		t.Fatal("expected job fires once the guard passes")
+	}
+}
+
+// List upcoming jobs, expect they are ordered by next run time without paused jobs.
+func TestUpcomingN(t *testing.T) {
+	start := parseTime("2024-11-06T10:20:30+08:00")
+	clock := newFakeClock(start)
+
+	beat := New(WithClock(clock), WithLocation(start.Location()))
+	beat.Add("* * * * * * 0", "TestUpcomingN-minutely", nil, nil)
+	beat.Add("* * * * * 0 0", "TestUpcomingN-hourly", nil, nil)
+	beat.Add("* * * * * * */10", "TestUpcomingN-10s", nil, nil)
+	beat.Add("* * * * * * *", "TestUpcomingN-paused", nil, nil)
+	beat.Pause("TestUpcomingN-paused")
+	beat.Start()
+	defer beat.Stop()
+
+	var ids []string
+	for _, entry := range beat.UpcomingN(10) {
+		ids = append(ids, entry.Id)
+	}
+	expected := []string{"TestUpcomingN-10s", "TestUpcomingN-minutely", "TestUpcomingN-hourly"}
+	if !slices.Equal(ids, expected) {
+		t.Errorf("expected %v, got %v", expected, ids)
+	}
+
+	if upcoming := beat.UpcomingN(1); len(upcoming) != 1 || upcoming[0].Id != "TestUpcomingN-10s" {
+		t.Errorf("unexpected upcoming jobs %v", upcoming)
+	}
+	if upcoming := beat.UpcomingN(0); len(upcoming) != 0 {
+		t.Errorf("expected no upcoming jobs, got %v", upcoming)
+	}
+}
+
+// Wake with a due job after stopping has begun, expect the job is not launched.
+func TestStopDuringWake(t *testing.T) {
+	start := parseTime("2024-11-06T10:20:30+08:00")
+	clock := newFakeClock(start)
+	fired := make(chan struct{}, 1)
+
+	beat := New(WithClock(clock), WithLocation(start.Location()))
+	beat.Add("* * * * * * *", "TestStopDuringWake-1",
+		func(ctx context.Context, userdata any) { fired <- struct{}{} },
+		nil)
+	beat.Start()
+
+	clock.BlockUntil(1)
+	// Stop sets the flag before its request reaches the scheduler, the wake may be handled first.
+	beat.stopping.Store(true)
+	clock.Advance(time.Second)
+	beat.Stop()
+
+	select {
+	case <-fired:
+		t.Fatal("expected due job is not launched while stopping")
+	default:
+	}
+
+	// The flag is cleared after stopping, so the job fires again after restart.
+	beat.Start()
+	defer beat.Stop()
+
+	clock.BlockUntil(1)
+	clock.Advance(time.Second)
+
+	select {
+	case <-fired:
+	case <-time.After(OneSecond):
+		t.Fatal("expected job fires after restart")
+	}
+}
+
+// Fire a one-shot job with auto removal, expect it is removed once finished while others are kept.
+func TestAutoRemoveFinished(t *testing.T) {
+	for _, autoRemove := range []bool{false, true} {
+		start := parseTime("2024-11-06T10:20:30+08:00")
+		clock := newFakeClock(start)
+		fired := make(chan struct{}, 1)
+
+		opts := []option{WithClock(clock), WithLocation(start.Location())}
+		if autoRemove {
+			opts = append(opts, WithAutoRemoveFinished())
+		}
+
+		beat := New(opts...)
+		beat.AddSchedule(At(start.Add(time.Second)), "TestAutoRemoveFinished-once",
+			func(ctx context.Context, userdata any) { fired <- struct{}{} },
+			nil)
+		beat.Add("* * * * * * *", "TestAutoRemoveFinished-every", nil, nil)
+		beat.AddAfter("TestAutoRemoveFinished-every", "TestAutoRemoveFinished-after", nil, nil)
+		beat.Start()
+
+		clock.BlockUntil(1)
+		clock.Advance(time.Second)
+
+		select {
+		case <-fired:
+		case <-time.After(OneSecond):
+			t.Fatal("expected one-shot job fires")
+		}
+
+		if beat.Contains("TestAutoRemoveFinished-once") == autoRemove {
+			t.Errorf("expected one-shot job is removed %v", autoRemove)
+		}
+		if !beat.Contains("TestAutoRemoveFinished-every") || !beat.Contains("TestAutoRemoveFinished-after") {
+			t.Error("expected other jobs are kept")
+		}
+
+		beat.Stop()
+	}
+}
+
+// Add one-shot jobs with auto pruning, expect jobs never firing again are removed whenever scheduled.
+func TestAutoPrune(t *testing.T) {
+	start := parseTime("2024-11-06T10:20:30+08:00")
+	clock := newFakeClock(start)
+	fired := make(chan struct{}, 1)
+
+	beat := New(WithClock(clock), WithLocation(start.Location()), WithAutoPrune())
+	beat.AddSchedule(At(start.Add(-time.Second)), "TestAutoPrune-past", nil, nil)
+	beat.AddSchedule(At(start.Add(time.Second)), "TestAutoPrune-once",
+		func(ctx context.Context, userdata any) { fired <- struct{}{} },
+		nil)
+	beat.AddAfter("TestAutoPrune-once", "TestAutoPrune-after", nil, nil)
+	beat.Start()
+	defer beat.Stop()
+
+	if beat.Contains("TestAutoPrune-past") {
+		t.Error("expected past job is pruned on start")
+	}
+
+	beat.AddSchedule(At(start.Add(-time.Second)), "TestAutoPrune-added", nil, nil)
+	if beat.Contains("TestAutoPrune-added") {
+		t.Error("expected past job is pruned once added")
+	}
+	if !beat.Contains("TestAutoPrune-once") {
+		t.Error("expected pending job is kept")
+	}
+
+	beat.AddSchedule(At(start.Add(time.Hour)), "TestAutoPrune-rescheduled", nil, nil)
+	beat.Reschedule("TestAutoPrune-rescheduled", At(start.Add(-time.Second)))
+	if beat.Contains("TestAutoPrune-rescheduled") {
+		t.Error("expected job rescheduled to the past is pruned")
+	}
+
+	clock.BlockUntil(1)
+	clock.Advance(time.Second)
+
+	select {
+	case <-fired:
+	case <-time.After(OneSecond):
+		t.Fatal("expected one-shot job fires")
+	}
+
+	if beat.Contains("TestAutoPrune-once") {
+		t.Error("expected fired job is pruned")
+	}
+	if !beat.Contains("TestAutoPrune-after") {
+		t.Error("expected downstream job is kept")
+	}
+}
@@ -0,0 +1,74 @@
+package beat
+
+import (
+	"container/list"
+	"sync"
+)
+
+// 解析缓存的默认容量
+const defaultCacheSize = 256
+
+// 按时间表达式缓存解析结果的 LRU 缓存
+//
+// 缓存中的 Schedule 只由解析器持有，解析器返回其副本
+type scheduleCache struct {
+	lock  sync.Mutex
+	size  int
+	order *list.List               // 按最近使用排列，最近使用的在前
+	items map[string]*list.Element // 时间表达式到 order 中元素的映射
+}
+
+type cacheItem struct {
+	expr     string
+	schedule Schedule
+}
+
+func newScheduleCache(size int) *scheduleCache {
+	return &scheduleCache{
+		size:  size,
+		order: list.New(),
+		items: make(map[string]*list.Element),
+	}
+}
+
+// 获取缓存的解析结果
+func (c *scheduleCache) get(expr string) (Schedule, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	elem, ok := c.items[expr]
+	if !ok {
+		return nil, false
+	}
+
+	c.order.MoveToFront(elem)
+	return elem.Value.(*cacheItem).schedule, true
+}
+
+// 缓存解析结果，超过容量时淘汰最久未使用的结果
+func (c *scheduleCache) put(expr string, schedule Schedule) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if elem, ok := c.items[expr]; ok {
+		elem.Value.(*cacheItem).schedule = schedule
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.items[expr] = c.order.PushFront(&cacheItem{expr: expr, schedule: schedule})
+
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*cacheItem).expr)
+	}
+}
+
+// 获取缓存的结果数量
+func (c *scheduleCache) len() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.order.Len()
+}
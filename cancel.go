@@ -0,0 +1,60 @@
+package beat
+
+import (
+	"context"
+	"sync"
+)
+
+// 正在执行的任务的取消函数，按任务ID分组，同一任务可能同时有多次执行
+type runCancels struct {
+	lock  sync.Mutex
+	seq   uint64
+	funcs map[string]map[uint64]context.CancelFunc
+}
+
+// 记录一次执行的取消函数，返回执行结束时移除记录的函数
+func (c *runCancels) add(id string, cancel context.CancelFunc) func() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.funcs == nil {
+		c.funcs = make(map[string]map[uint64]context.CancelFunc)
+	}
+	if c.funcs[id] == nil {
+		c.funcs[id] = make(map[uint64]context.CancelFunc)
+	}
+
+	c.seq++
+	seq := c.seq
+	c.funcs[id][seq] = cancel
+
+	return func() {
+		c.lock.Lock()
+		defer c.lock.Unlock()
+
+		delete(c.funcs[id], seq)
+		if len(c.funcs[id]) == 0 {
+			delete(c.funcs, id)
+		}
+	}
+}
+
+// 取消任务正在进行的所有执行，返回取消的执行次数
+func (c *runCancels) cancel(id string) int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for _, cancel := range c.funcs[id] {
+		cancel()
+	}
+
+	return len(c.funcs[id])
+}
+
+// 取消指定任务正在进行的执行，不影响之后的执行及其他任务
+//
+// 取消传递给该任务执行的上下文，包括等待协程数量限制和重试的执行；任务需监听 ctx.Done() 才能及时退出。
+// 任务被移除后仍可取消其正在进行的执行。返回是否有正在进行的执行被取消
+func (b *Beat) Cancel(id string) bool {
+	return b.runCancels.cancel(id) > 0
+}
@@ -0,0 +1,55 @@
+package beat
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// Cancel one of two running jobs, expect only its context is canceled.
+func TestCancel(t *testing.T) {
+	started := make(chan string, 2)
+	canceled := make(chan string, 2)
+	fn := func(ctx context.Context, userdata any) {
+		started <- userdata.(string)
+		select {
+		case <-ctx.Done():
+			canceled <- userdata.(string)
+		case <-time.After(OneSecond):
+		}
+	}
+
+	beat := New()
+	beat.Add("* 1 1 * 0 0 0", "TestCancel-1", fn, "1")
+	beat.Add("* 1 1 * 0 0 0", "TestCancel-2", fn, "2")
+	beat.Start()
+	defer beat.Stop()
+
+	if beat.Cancel("TestCancel-1") {
+		t.Error("expected nothing to cancel before the job runs")
+	}
+
+	beat.RunNow("TestCancel-1")
+	beat.RunNow("TestCancel-2")
+	<-started
+	<-started
+
+	if !beat.Cancel("TestCancel-1") {
+		t.Error("expected running job is canceled")
+	}
+
+	select {
+	case id := <-canceled:
+		if id != "1" {
+			t.Errorf("expected job 1 is canceled, got %s", id)
+		}
+	case <-time.After(OneSecond):
+		t.Fatal("expected job context is canceled")
+	}
+
+	select {
+	case id := <-canceled:
+		t.Errorf("expected job %s is not canceled", id)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
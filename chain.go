@@ -0,0 +1,70 @@
+package beat
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// 任务中间件，用于包装任务以添加通用行为
+type JobWrapper func(JobFunc) JobFunc
+
+// 用于在 JobWrapper 链中传递任务返回的错误
+type errSlotKey struct{}
+
+// 使用 JobWrapper 链包装任务，第一个 JobWrapper 位于最外层
+//
+// 任务返回的错误通过上下文传递，因此 JobWrapper 需要在调用中同步执行被包装的任务，
+// 并传递其接收到的上下文
+func wrapJob(fn JobFuncE, wrappers []JobWrapper) JobFuncE {
+	if len(wrappers) == 0 {
+		return fn
+	}
+
+	wrapped := JobFunc(func(ctx context.Context, userdata any) {
+		err := fn(ctx, userdata)
+		if slot, ok := ctx.Value(errSlotKey{}).(*error); ok {
+			*slot = err
+		}
+	})
+	for i := len(wrappers) - 1; i >= 0; i-- {
+		wrapped = wrappers[i](wrapped)
+	}
+
+	return func(ctx context.Context, userdata any) error {
+		var err error
+		wrapped(context.WithValue(ctx, errSlotKey{}, &err), userdata)
+		return err
+	}
+}
+
+// 返回捕获任务 panic 并记录日志的 JobWrapper
+func Recover(log Logger) JobWrapper {
+	return func(fn JobFunc) JobFunc {
+		return func(ctx context.Context, userdata any) {
+			defer func() {
+				if r := recover(); r != nil {
+					log.Error("panic", r, "statck", string(stack(0)))
+				}
+			}()
+
+			fn(ctx, userdata)
+		}
+	}
+}
+
+// 返回在上一次执行未结束时跳过本次执行的 JobWrapper
+func SkipIfStillRunning(log Logger) JobWrapper {
+	return func(fn JobFunc) JobFunc {
+		var running atomic.Bool
+
+		return func(ctx context.Context, userdata any) {
+			if !running.CompareAndSwap(false, true) {
+				log.Debug("job.action", "skip")
+				return
+			}
+			defer running.Store(false)
+
+			fn(ctx, userdata)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package beat
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func appendWrapper(lock *sync.Mutex, trace *[]string, name string) JobWrapper {
+	return func(fn JobFunc) JobFunc {
+		return func(ctx context.Context, userdata any) {
+			lock.Lock()
+			*trace = append(*trace, name)
+			lock.Unlock()
+
+			fn(ctx, userdata)
+		}
+	}
+}
+
+// Add a job with global and per-job wrappers, expect they are applied in order
+// and the job error still reaches the error handler.
+func TestChain(t *testing.T) {
+	var lock sync.Mutex
+	trace := []string{}
+	errJob := errors.New("job failed")
+	ch := make(chan error, 1)
+
+	beat := New(
+		WithChain(appendWrapper(&lock, &trace, "a"), appendWrapper(&lock, &trace, "b")),
+		WithErrorHandler(func(id string, err error) { ch <- err }),
+	)
+	beat.AddE("* 1 1 * 0 0 0", "TestChain-1",
+		func(ctx context.Context, userdata any) error {
+			lock.Lock()
+			trace = append(trace, "job")
+			lock.Unlock()
+			return errJob
+		},
+		nil, WithWrappers(appendWrapper(&lock, &trace, "c")))
+
+	beat.RunNow("TestChain-1")
+
+	select {
+	case <-time.After(OneSecond):
+		t.Fatal("expected error is reported")
+	case err := <-ch:
+		if !errors.Is(err, errJob) {
+			t.Errorf("expected %v, got %v", errJob, err)
+		}
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+	if actual := strings.Join(trace, ","); actual != "a,b,c,job" {
+		t.Errorf("expected a,b,c,job, got %s", actual)
+	}
+}
+
+// Wrap a panicking job with Recover, expect the panic does not escape.
+func TestRecoverWrapper(t *testing.T) {
+	fn := Recover(defaultLogger)(func(ctx context.Context, userdata any) {
+		panic("panic in beat")
+	})
+
+	fn(context.Background(), nil)
+}
+
+// Wrap a blocking job with SkipIfStillRunning, expect overlapping calls are skipped.
+func TestSkipIfStillRunningWrapper(t *testing.T) {
+	var calls int64
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	fn := SkipIfStillRunning(defaultLogger)(func(ctx context.Context, userdata any) {
+		atomic.AddInt64(&calls, 1)
+		close(started)
+		<-release
+	})
+
+	go fn(context.Background(), nil)
+	<-started
+
+	fn(context.Background(), nil)
+	close(release)
+
+	if n := atomic.LoadInt64(&calls); n != 1 {
+		t.Errorf("called %d times, expected 1", n)
+	}
+}
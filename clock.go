@@ -0,0 +1,52 @@
+package beat
+
+import "time"
+
+// 时钟，用于获取当前时间和创建定时器
+//
+// 默认使用系统时钟，可通过 WithClock 替换，便于测试
+type Clock interface {
+	// 返回当前时间
+	Now() time.Time
+	// 创建定时器，定时器在 d 后触发
+	NewTimer(d time.Duration) Timer
+}
+
+// 定时器，与 time.Timer 的行为一致
+type Timer interface {
+	// 返回定时器触发时接收时间的通道
+	C() <-chan time.Time
+	// 停止定时器
+	Stop() bool
+	// 重置定时器，定时器在 d 后触发
+	Reset(d time.Duration) bool
+}
+
+// 系统时钟
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) NewTimer(d time.Duration) Timer {
+	return realTimer{time.NewTimer(d)}
+}
+
+type realTimer struct {
+	*time.Timer
+}
+
+func (t realTimer) C() <-chan time.Time {
+	return t.Timer.C
+}
+
+// 使用自定义函数获取当前时间的时钟，定时器仍然使用系统时钟
+type nowFuncClock struct {
+	realClock
+	now func() time.Time
+}
+
+func (c nowFuncClock) Now() time.Time {
+	return c.now()
+}
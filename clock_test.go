@@ -0,0 +1,191 @@
+package beat
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeClock is a manually advanced clock for deterministic tests.
+type fakeClock struct {
+	lock   sync.Mutex
+	now    time.Time
+	timers []*fakeTimer
+}
+
+type fakeTimer struct {
+	clock  *fakeClock
+	c      chan time.Time
+	when   time.Time
+	active bool
+}
+
+func newFakeClock(now time.Time) *fakeClock {
+	return &fakeClock{now: now}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.now
+}
+
+func (c *fakeClock) NewTimer(d time.Duration) Timer {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	t := &fakeTimer{clock: c, c: make(chan time.Time, 1)}
+	c.timers = append(c.timers, t)
+	c.reset(t, d)
+
+	return t
+}
+
+// Advance moves the clock forward and fires all expired timers.
+func (c *fakeClock) Advance(d time.Duration) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.now = c.now.Add(d)
+	for _, t := range c.timers {
+		c.fire(t)
+	}
+}
+
+// BlockUntil waits until n timers are active.
+func (c *fakeClock) BlockUntil(n int) {
+	for {
+		c.lock.Lock()
+		active := 0
+		for _, t := range c.timers {
+			if t.active {
+				active++
+			}
+		}
+		c.lock.Unlock()
+
+		if active >= n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func (c *fakeClock) reset(t *fakeTimer, d time.Duration) bool {
+	wasActive := t.active
+	t.when = c.now.Add(d)
+	t.active = true
+	c.fire(t)
+
+	return wasActive
+}
+
+func (c *fakeClock) fire(t *fakeTimer) {
+	if t.active && !t.when.After(c.now) {
+		t.active = false
+		select {
+		case t.c <- c.now:
+		default:
+		}
+	}
+}
+
+func (t *fakeTimer) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *fakeTimer) Stop() bool {
+	t.clock.lock.Lock()
+	defer t.clock.lock.Unlock()
+
+	wasActive := t.active
+	t.active = false
+
+	return wasActive
+}
+
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	t.clock.lock.Lock()
+	defer t.clock.lock.Unlock()
+
+	return t.clock.reset(t, d)
+}
+
+// Advance a fake clock, expect the job fires at the expected instants.
+func TestClock(t *testing.T) {
+	start := parseTime("2024-11-06T10:20:30+08:00")
+	clock := newFakeClock(start)
+	fired := make(chan time.Time, 1)
+
+	beat := New(WithClock(clock), WithLocation(start.Location()))
+	beat.Add("* * * * * * */10", "TestClock-1",
+		func(ctx context.Context, userdata any) { fired <- clock.Now() },
+		nil)
+	beat.Start()
+	defer beat.Stop()
+
+	expected := []string{
+		"2024-11-06T10:20:40+08:00",
+		"2024-11-06T10:20:50+08:00",
+		"2024-11-06T10:21:00+08:00",
+	}
+
+	for _, item := range expected {
+		clock.BlockUntil(1)
+		clock.Advance(5 * time.Second)
+
+		select {
+		case tm := <-fired:
+			t.Fatalf("expected job does not fire at %s", tm)
+		case <-time.After(10 * time.Millisecond):
+		}
+
+		clock.BlockUntil(1)
+		clock.Advance(5 * time.Second)
+
+		select {
+		case tm := <-fired:
+			if expected := parseTime(item); !tm.Equal(expected) {
+				t.Errorf("(expected) %s != %s (actual)", expected, tm)
+			}
+		case <-time.After(OneSecond):
+			t.Fatalf("expected job fires at %s", item)
+		}
+	}
+}
+
+// Compute run times from a custom now function, expect jobs fire once it reaches their run times.
+func TestNowFunc(t *testing.T) {
+	start := parseTime("2024-11-06T10:20:30.9+08:00")
+	var current atomic.Value
+	current.Store(start)
+	fired := make(chan time.Time, 1)
+
+	beat := New(WithNowFunc(func() time.Time { return current.Load().(time.Time) }), WithLocation(start.Location()))
+	beat.AddE("* * * * * * *", "TestNowFunc-1", func(ctx context.Context, userdata any) error {
+		info, _ := JobInfoFromContext(ctx)
+		fired <- info.Scheduled
+		return nil
+	}, nil)
+	beat.Start()
+	defer beat.Stop()
+
+	expected := parseTime("2024-11-06T10:20:31+08:00")
+	if entry, _ := beat.Entry("TestNowFunc-1"); !entry.Next.Equal(expected) {
+		t.Errorf("expected next run time %s, got %s", expected, entry.Next)
+	}
+
+	current.Store(expected)
+
+	select {
+	case scheduled := <-fired:
+		if !scheduled.Equal(expected) {
+			t.Errorf("expected job fires at %s, got %s", expected, scheduled)
+		}
+	case <-time.After(OneSecond):
+		t.Fatal("expected job fires")
+	}
+}
@@ -3,7 +3,18 @@ package beat
 import "errors"
 
 var (
-	ErrInvalidExp  = errors.New("invalid expression")
-	ErrJobExist    = errors.New("job already exists")
-	ErrJobNotExist = errors.New("job does not exists")
+	ErrInvalidExp    = errors.New("invalid expression")
+	ErrJobExist      = errors.New("job already exists")
+	ErrJobNotExist   = errors.New("job does not exists")
+	ErrEmptyId       = errors.New("job id is empty")
+	ErrInvalidCount  = errors.New("invalid count")
+	ErrNilSchedule   = errors.New("schedule is nil")
+	ErrUnschedulable = errors.New("schedule never fires")
+	ErrBusy          = errors.New("scheduler is busy")
+	ErrTooFrequent   = errors.New("schedule fires too frequently")
+	ErrInvalidWeight = errors.New("job weight exceeds max goroutines")
+	ErrRunning       = errors.New("beat is running")
+	ErrRestricted    = errors.New("expression is not allowed")
+	ErrInvalidDelay  = errors.New("delay must be positive")
+	ErrCyclicAfter   = errors.New("job runs after itself")
 )
@@ -0,0 +1,90 @@
+package beat
+
+import "time"
+
+// 事件通道的默认缓冲大小
+const defaultEventBuffer = 64
+
+// 事件类型
+type EventType int
+
+const (
+	EventAdded     EventType = iota // 任务已添加
+	EventRemoved                    // 任务已移除
+	EventStarted                    // 任务开始执行
+	EventCompleted                  // 任务执行完成
+	EventPanicked                   // 任务执行时 panic，仅在启用 recover 时发送
+)
+
+func (t EventType) String() string {
+	switch t {
+	case EventAdded:
+		return "added"
+	case EventRemoved:
+		return "removed"
+	case EventStarted:
+		return "started"
+	case EventCompleted:
+		return "completed"
+	case EventPanicked:
+		return "panicked"
+	}
+
+	return "unknown"
+}
+
+// 调度事件
+type Event struct {
+	Type  EventType // 事件类型
+	JobId string    // 任务ID
+	Time  time.Time // 事件发生的时间
+}
+
+// 订阅调度事件
+//
+// 首次调用时创建事件通道，之后返回同一个通道。通道在 Stop 时关闭，
+// 重新开始运行后需要再次调用以获取新的通道。
+// 默认情况下通道已满时丢弃事件，可通过 WithEventBlocking 改为阻塞
+func (b *Beat) Events() <-chan Event {
+	b.eventLock.Lock()
+	defer b.eventLock.Unlock()
+
+	if b.events == nil {
+		b.events = make(chan Event, b.eventBuffer)
+	}
+
+	return b.events
+}
+
+// 发送事件，未订阅时不做处理
+func (b *Beat) emit(typ EventType, id string) {
+	b.eventLock.Lock()
+	defer b.eventLock.Unlock()
+
+	if b.events == nil {
+		return
+	}
+
+	event := Event{Type: typ, JobId: id, Time: b.clock.Now()}
+
+	if b.eventBlocking {
+		b.events <- event
+		return
+	}
+
+	select {
+	case b.events <- event:
+	default:
+	}
+}
+
+// 关闭事件通道
+func (b *Beat) closeEvents() {
+	b.eventLock.Lock()
+	defer b.eventLock.Unlock()
+
+	if b.events != nil {
+		close(b.events)
+		b.events = nil
+	}
+}
@@ -0,0 +1,66 @@
+package beat
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func nextEvent(t *testing.T, events <-chan Event) Event {
+	t.Helper()
+
+	select {
+	case <-time.After(OneSecond):
+		t.Fatal("expected event")
+	case event := <-events:
+		return event
+	}
+
+	return Event{}
+}
+
+// Subscribe to events, expect the job lifecycle is reported and the channel is closed on stop.
+func TestEvents(t *testing.T) {
+	id := "TestEvents-1"
+
+	beat := New(WithRecovery())
+	events := beat.Events()
+
+	beat.Add("* 1 1 * 0 0 0", id, func(ctx context.Context, userdata any) {}, nil)
+	beat.Add("* 1 1 * 0 0 0", "TestEvents-2", func(ctx context.Context, userdata any) { panic("panic in beat") }, nil)
+	beat.Start()
+
+	beat.RunNow(id)
+
+	expected := []EventType{EventAdded, EventAdded, EventStarted, EventCompleted}
+	for _, typ := range expected {
+		if event := nextEvent(t, events); event.Type != typ {
+			t.Fatalf("expected %s event, got %s", typ, event.Type)
+		}
+	}
+
+	beat.RunNow("TestEvents-2")
+
+	for _, typ := range []EventType{EventStarted, EventPanicked} {
+		if event := nextEvent(t, events); event.Type != typ || event.JobId != "TestEvents-2" {
+			t.Fatalf("expected %s event, got %+v", typ, event)
+		}
+	}
+
+	beat.Remove(id)
+
+	if event := nextEvent(t, events); event.Type != EventRemoved || event.JobId != id {
+		t.Fatalf("expected removed event, got %+v", event)
+	}
+
+	beat.Stop()
+
+	select {
+	case <-time.After(OneSecond):
+		t.Fatal("expected event channel is closed")
+	case _, ok := <-events:
+		if ok {
+			t.Fatal("expected no more events")
+		}
+	}
+}
@@ -0,0 +1,20 @@
+package beat_test
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cyberxnomad/beat"
+)
+
+func ExampleBeat_AddBatch() {
+	b := beat.New()
+	fn := func(ctx context.Context, userdata any) {}
+
+	err := b.AddBatch([]beat.JobSpec{
+		{Expr: "@every 1m", Id: "report", Func: fn, Options: []beat.JobOption{beat.WithTags("daily")}},
+		{Expr: "@hourly", Id: "cleanup", Func: fn, Options: []beat.JobOption{beat.WithSkipIfStillRunning()}},
+	})
+	fmt.Println(err, b.Count())
+	// Output: <nil> 2
+}
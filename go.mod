@@ -2,4 +2,7 @@ module github.com/cyberxnomad/beat
 
 go 1.23.0
 
-require golang.org/x/sync v0.14.0
+require (
+	golang.org/x/sync v0.14.0
+	golang.org/x/time v0.12.0
+)
@@ -0,0 +1,74 @@
+package beat
+
+import "time"
+
+// 任务句柄，用于操作指定的任务
+//
+// 句柄只记录任务ID，与调度器是否运行无关；任务被移除后，句柄的方法返回 ErrJobNotExist 或零值，
+// 之后再次添加同一ID的任务时，句柄将操作新的任务
+type Handle struct {
+	beat *Beat
+	id   string
+}
+
+// 添加任务并返回任务句柄
+//
+// 参数与 Add 相同，添加失败时返回 nil 和错误
+func (b *Beat) AddHandle(expr string, id string, fn JobFunc, userdata any, opts ...JobOption) (*Handle, error) {
+	if err := b.Add(expr, id, fn, userdata, opts...); err != nil {
+		return nil, err
+	}
+
+	return &Handle{beat: b, id: id}, nil
+}
+
+// 获取任务句柄
+//
+// 任务不存在时第二个返回值为 false
+func (b *Beat) Handle(id string) (*Handle, bool) {
+	if !b.Contains(id) {
+		return nil, false
+	}
+
+	return &Handle{beat: b, id: id}, true
+}
+
+// 获取任务ID
+func (h *Handle) Id() string {
+	return h.id
+}
+
+// 获取任务的快照
+//
+// 任务不存在时第二个返回值为 false
+func (h *Handle) Entry() (Entry, bool) {
+	return h.beat.Entry(h.id)
+}
+
+// 获取任务的下一次运行时间
+//
+// 任务不存在、已暂停或调度器从未运行时返回零值；调度器停止后返回停止前计算的下一次运行时间
+func (h *Handle) Next() time.Time {
+	entry, _ := h.beat.Entry(h.id)
+	return entry.Next
+}
+
+// 移除任务
+func (h *Handle) Remove() {
+	h.beat.Remove(h.id)
+}
+
+// 暂停任务
+func (h *Handle) Pause() error {
+	return h.beat.Pause(h.id)
+}
+
+// 恢复任务
+func (h *Handle) Resume() error {
+	return h.beat.Resume(h.id)
+}
+
+// 立即执行任务
+func (h *Handle) RunNow() error {
+	return h.beat.RunNow(h.id)
+}
@@ -0,0 +1,78 @@
+package beat
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// Operate a job through its handle across a restart, expect the handle follows the job id.
+func TestHandle(t *testing.T) {
+	ran := make(chan struct{}, 1)
+
+	beat := New()
+	if _, err := beat.AddHandle("* * * * * * *", "", nil, nil); !errors.Is(err, ErrEmptyId) {
+		t.Errorf("expected %v, got %v", ErrEmptyId, err)
+	}
+
+	handle, err := beat.AddHandle("* 1 1 * 0 0 0", "TestHandle-1", func(ctx context.Context, userdata any) {
+		ran <- struct{}{}
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if handle.Id() != "TestHandle-1" {
+		t.Errorf("unexpected id %s", handle.Id())
+	}
+
+	if !handle.Next().IsZero() {
+		t.Error("expected no next run time before the beat runs")
+	}
+
+	beat.Start()
+	beat.Stop()
+	if handle.Next().IsZero() {
+		t.Error("expected next run time is kept after stop")
+	}
+	beat.Start()
+	defer beat.Stop()
+
+	if handle.Next().IsZero() {
+		t.Error("expected next run time after restart")
+	}
+
+	if err := handle.RunNow(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-ran:
+	case <-time.After(OneSecond):
+		t.Fatal("expected job runs")
+	}
+
+	if err := handle.Pause(); err != nil {
+		t.Fatal(err)
+	}
+	if !handle.Next().IsZero() {
+		t.Error("expected no next run time while paused")
+	}
+	if err := handle.Resume(); err != nil {
+		t.Fatal(err)
+	}
+
+	if same, ok := beat.Handle("TestHandle-1"); !ok || same.Id() != handle.Id() {
+		t.Error("expected handle of the existing job")
+	}
+
+	handle.Remove()
+	if _, ok := handle.Entry(); ok {
+		t.Error("expected job is removed")
+	}
+	if err := handle.Pause(); !errors.Is(err, ErrJobNotExist) {
+		t.Errorf("expected %v, got %v", ErrJobNotExist, err)
+	}
+	if _, ok := beat.Handle("TestHandle-1"); ok {
+		t.Error("expected no handle of a removed job")
+	}
+}
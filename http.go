@@ -0,0 +1,105 @@
+package beat
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Handler 返回的任务信息
+type handlerEntry struct {
+	Id     string       `json:"id"`
+	Expr   string       `json:"expr"`
+	Next   time.Time    `json:"next"`
+	Prev   time.Time    `json:"prev"`
+	Tags   []string     `json:"tags,omitempty"`
+	Paused bool         `json:"paused"`
+	Stats  handlerStats `json:"stats"`
+}
+
+// Handler 返回的任务执行统计
+type handlerStats struct {
+	Runs         uint64        `json:"runs"`
+	Successes    uint64        `json:"successes"`
+	Errors       uint64        `json:"errors"`
+	LastRun      time.Time     `json:"last_run"`
+	LastDuration time.Duration `json:"last_duration"`
+	LastError    string        `json:"last_error,omitempty"`
+}
+
+// 获取所有任务的信息，按任务ID排序
+func (b *Beat) handlerEntries() []handlerEntry {
+	entries := b.Entries()
+	result := make([]handlerEntry, 0, len(entries))
+
+	for _, entry := range entries {
+		item := handlerEntry{
+			Id:     entry.Id,
+			Expr:   entry.Expr,
+			Next:   entry.Next,
+			Prev:   entry.Prev,
+			Tags:   entry.Tags,
+			Paused: entry.Paused,
+		}
+
+		// 执行统计与任务信息来自同一个快照
+		stats := entry.Stats
+		item.Stats = handlerStats{
+			Runs:         stats.Runs,
+			Successes:    stats.Successes,
+			Errors:       stats.Errors,
+			LastRun:      stats.LastRun,
+			LastDuration: stats.LastDuration,
+		}
+		if stats.LastError != nil {
+			item.Stats.LastError = stats.LastError.Error()
+		}
+
+		result = append(result, item)
+	}
+
+	slices.SortFunc(result, func(a, b handlerEntry) int {
+		return strings.Compare(a.Id, b.Id)
+	})
+
+	return result
+}
+
+// 返回以 JSON 格式展示任务的 http.Handler，用于调试
+//
+// GET 返回所有任务的ID、定时表达式、运行时间和执行统计，不包括任务回调和用户数据；
+// POST ?id=<任务ID> 立即执行任务，任务不存在时返回 404
+func (b *Beat) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead:
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(b.handlerEntries())
+
+		case http.MethodPost:
+			id := r.URL.Query().Get("id")
+			if id == "" {
+				http.Error(w, ErrEmptyId.Error(), http.StatusBadRequest)
+				return
+			}
+
+			if err := b.RunNow(id); err != nil {
+				if errors.Is(err, ErrJobNotExist) {
+					http.Error(w, err.Error(), http.StatusNotFound)
+				} else {
+					http.Error(w, err.Error(), http.StatusInternalServerError)
+				}
+				return
+			}
+
+			w.WriteHeader(http.StatusAccepted)
+
+		default:
+			w.Header().Set("Allow", "GET, HEAD, POST")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		}
+	})
+}
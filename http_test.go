@@ -0,0 +1,75 @@
+package beat
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// List jobs, run one now and use an unsupported method through the handler.
+func TestHandler(t *testing.T) {
+	ran := make(chan struct{}, 1)
+
+	beat := New()
+	beat.Add("@hourly", "TestHandler-2", nil, "secret")
+	beat.Add("* 1 1 * 0 0 0", "TestHandler-1", func(ctx context.Context, userdata any) {
+		ran <- struct{}{}
+	}, nil, WithTags("debug"))
+	beat.Start()
+	defer beat.Stop()
+
+	handler := beat.Handler()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/cron", nil))
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("unexpected response %d %s", rec.Code, rec.Header().Get("Content-Type"))
+	}
+
+	var entries []map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &entries); err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0]["id"] != "TestHandler-1" || entries[1]["expr"] != "@hourly" {
+		t.Errorf("unexpected entries %v", entries)
+	}
+	if _, ok := entries[0]["stats"]; !ok {
+		t.Errorf("expected stats are included, got %v", entries[0])
+	}
+	for _, key := range []string{"userdata", "func", "Userdata", "Func"} {
+		if _, ok := entries[1][key]; ok {
+			t.Errorf("expected %s is not exposed", key)
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/cron?id=TestHandler-1", nil))
+	if rec.Code != http.StatusAccepted {
+		t.Errorf("expected %d, got %d", http.StatusAccepted, rec.Code)
+	}
+	select {
+	case <-ran:
+	case <-time.After(OneSecond):
+		t.Fatal("expected job runs")
+	}
+
+	tests := []struct {
+		method string
+		target string
+		code   int
+	}{
+		{http.MethodPost, "/cron?id=unknown", http.StatusNotFound},
+		{http.MethodPost, "/cron", http.StatusBadRequest},
+		{http.MethodDelete, "/cron", http.StatusMethodNotAllowed},
+	}
+	for _, test := range tests {
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(test.method, test.target, nil))
+		if rec.Code != test.code {
+			t.Errorf("%s %s: expected %d, got %d", test.method, test.target, test.code, rec.Code)
+		}
+	}
+}
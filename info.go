@@ -0,0 +1,29 @@
+package beat
+
+import (
+	"context"
+	"time"
+)
+
+// 任务执行时的信息，可在任务中通过 JobInfoFromContext 获取
+type JobInfo struct {
+	Id        string    // 任务ID
+	Scheduled time.Time // 本次执行对应的运行时间，RunNow 时为调用时的时间
+	Attempt   int       // 第几次尝试，从 1 开始，重试时递增
+}
+
+// 上下文中保存 JobInfo 的键
+type jobInfoKey struct{}
+
+// 返回带有任务信息的上下文
+func withJobInfo(ctx context.Context, info JobInfo) context.Context {
+	return context.WithValue(ctx, jobInfoKey{}, info)
+}
+
+// 从传递给任务的上下文中获取任务信息
+//
+// ctx 不是由调度器传递给任务的上下文时，第二个返回值为 false
+func JobInfoFromContext(ctx context.Context) (JobInfo, bool) {
+	info, ok := ctx.Value(jobInfoKey{}).(JobInfo)
+	return info, ok
+}
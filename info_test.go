@@ -0,0 +1,46 @@
+package beat
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// Retry a scheduled job once, expect each attempt sees its id, scheduled time and attempt number.
+func TestJobInfoFromContext(t *testing.T) {
+	if _, ok := JobInfoFromContext(context.Background()); ok {
+		t.Error("expected no job info in a plain context")
+	}
+
+	start := parseTime("2024-11-06T10:20:30+08:00")
+	clock := newFakeClock(start)
+	infos := make(chan JobInfo, 2)
+
+	beat := New(WithClock(clock), WithLocation(start.Location()), WithErrorHandler(func(string, error) {}))
+	beat.AddE("* * * * * * */10", "TestJobInfoFromContext-1", func(ctx context.Context, userdata any) error {
+		info, _ := JobInfoFromContext(ctx)
+		infos <- info
+		if info.Attempt == 1 {
+			return errors.New("job failed")
+		}
+		return nil
+	}, nil, WithRetry(1, nil))
+	beat.Start()
+	defer beat.Stop()
+
+	clock.BlockUntil(1)
+	clock.Advance(10 * time.Second)
+
+	scheduled := parseTime("2024-11-06T10:20:40+08:00")
+	for attempt := 1; attempt <= 2; attempt++ {
+		select {
+		case info := <-infos:
+			if info.Id != "TestJobInfoFromContext-1" || !info.Scheduled.Equal(scheduled) || info.Attempt != attempt {
+				t.Errorf("unexpected job info %+v", info)
+			}
+		case <-time.After(OneSecond):
+			t.Fatalf("expected attempt %d runs", attempt)
+		}
+	}
+}
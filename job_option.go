@@ -0,0 +1,128 @@
+package beat
+
+import (
+	"context"
+	"slices"
+	"time"
+)
+
+// JobOption configures a single job, it is accepted by Add and its variants and by JobSpec.Options.
+type JobOption func(*job)
+
+// WithSkipIfStillRunning allows to skip a scheduled execution if the previous one is still running.
+//
+// Default is to start a new execution regardless.
+func WithSkipIfStillRunning() JobOption {
+	return func(j *job) {
+		j.skipIfRunning = true
+	}
+}
+
+// WithDelayIfStillRunning allows to delay a scheduled execution until the previous one finishes.
+//
+// Executions of the job are serialized, other jobs are not affected.
+func WithDelayIfStillRunning() JobOption {
+	return func(j *job) {
+		j.delayIfRunning = true
+	}
+}
+
+// WithJobTimeout allows to specify a timeout for each execution of the job.
+//
+// The context passed to the job is cancelled when the timeout elapses. Default is 0, 0 means no timeout.
+func WithJobTimeout(d time.Duration) JobOption {
+	return func(j *job) {
+		j.timeout = d
+	}
+}
+
+// WithRetry allows to retry the job up to maxAttempts times when it returns an error.
+//
+// backoff returns the delay before the nth retry, starting from 1; nil means no delay.
+// Retries happen within the same execution and stop when the job's context is done.
+func WithRetry(maxAttempts int, backoff func(attempt int) time.Duration) JobOption {
+	return func(j *job) {
+		j.retries = max(maxAttempts, 0)
+		j.backoff = backoff
+	}
+}
+
+// WithWrappers allows to specify wrappers applied to the job, inside the ones specified by WithChain.
+func WithWrappers(wrappers ...JobWrapper) JobOption {
+	return func(j *job) {
+		j.wrappers = wrappers
+	}
+}
+
+// WithJobJitter allows to delay each execution of the job by a random duration in [0, max),
+// overriding the one specified by WithJitter.
+func WithJobJitter(max time.Duration) JobOption {
+	return func(j *job) {
+		j.jitter = max
+	}
+}
+
+// WithPriority allows to specify the priority of the job, default is 0.
+//
+// Among jobs due at the same time, those with a higher priority are started first,
+// and jobs with the same priority are started in the order they were added.
+func WithPriority(priority int) JobOption {
+	return func(j *job) {
+		j.priority = priority
+	}
+}
+
+// WithWeight allows to specify how many goroutines the job counts against the limit specified by WithMaxGoroutines.
+//
+// Default is 1, a weight less than 1 is treated as 1. Adding the job returns ErrInvalidWeight if the weight exceeds
+// the limit; if SetMaxGoroutines later lowers the limit below the weight, the job counts as the whole limit.
+func WithWeight(weight int64) JobOption {
+	return func(j *job) {
+		j.weight = max(weight, 1)
+	}
+}
+
+// WithLastRun allows to specify the time the job last ran, e.g. restored from storage.
+//
+// It is used by WithCatchUp to find the missed scheduled times.
+func WithLastRun(t time.Time) JobOption {
+	return func(j *job) {
+		j.Prev = t
+	}
+}
+
+// WithTags allows to specify tags of the job, jobs can be grouped by tags and removed together with RemoveByTag.
+func WithTags(tags ...string) JobOption {
+	return func(j *job) {
+		j.tags = slices.Clone(tags)
+	}
+}
+
+// WithBlackout allows to specify a predicate reporting times the job must not run at.
+//
+// A scheduled time in the blackout is skipped, and the next scheduled time outside it is used instead.
+// The job never runs if no time outside the blackout is found within 10000 scheduled times.
+func WithBlackout(blackout func(t time.Time) bool) JobOption {
+	return func(j *job) {
+		j.blackout = blackout
+	}
+}
+
+// WithGuard allows to specify a predicate evaluated each time the job is due, the execution is skipped if it returns false.
+//
+// The next run time advances normally regardless. The guard is called in the scheduler's goroutine with the job's
+// context and userdata, so it must be fast and must not call methods of the Beat. RunNow bypasses the guard.
+func WithGuard(guard func(ctx context.Context, userdata any) bool) JobOption {
+	return func(j *job) {
+		j.guard = guard
+	}
+}
+
+// WithTriggerOnFailure allows a job added with AddAfter to run even if the upstream job returns an error.
+//
+// Default is to run only after the upstream job succeeds.
+func WithTriggerOnFailure() JobOption {
+	return func(j *job) {
+		j.afterFailure = true
+	}
+}
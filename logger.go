@@ -20,6 +20,7 @@ type Logger interface {
 	Error(keyvals ...any)
 }
 
+// 默认 logger，输出到标准输出
 var defaultLogger Logger = &logger{
 	writer: os.Stdout,
 	pool: &sync.Pool{
@@ -29,6 +30,24 @@ var defaultLogger Logger = &logger{
 	},
 }
 
+// 设置之后通过 New 创建的 Beat 默认使用的 logger
+//
+// 应在创建 Beat 之前调用；log 为 nil 时使用 NopLogger
+func SetDefaultLogger(log Logger) {
+	if log == nil {
+		log = NopLogger{}
+	}
+	defaultLogger = log
+}
+
+// 丢弃所有日志的 logger，可通过 WithLogger(NopLogger{}) 关闭所有输出
+type NopLogger struct{}
+
+func (NopLogger) Debug(keyvals ...any) {}
+func (NopLogger) Info(keyvals ...any)  {}
+func (NopLogger) Warn(keyvals ...any)  {}
+func (NopLogger) Error(keyvals ...any) {}
+
 type logger struct {
 	writer io.Writer
 	lock   sync.Mutex
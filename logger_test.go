@@ -0,0 +1,40 @@
+package beat
+
+import (
+	"testing"
+)
+
+// Silence a beat with NopLogger and swap the default logger.
+func TestNopLogger(t *testing.T) {
+	beat := New(WithLogger(NopLogger{}))
+	beat.Start()
+	beat.Add("* * * * * * *", "TestNopLogger-1", nil, nil)
+	beat.Stop()
+
+	orig := defaultLogger
+	SetDefaultLogger(NopLogger{})
+	defer SetDefaultLogger(orig)
+
+	if _, ok := New().log.(NopLogger); !ok {
+		t.Error("expected the default logger is used by New")
+	}
+}
+
+// Set nil loggers, expect NopLogger is used instead and logging does not panic.
+func TestSetNilLogger(t *testing.T) {
+	beat := New()
+	beat.SetLogger(nil)
+	if _, ok := beat.log.(NopLogger); !ok {
+		t.Errorf("expected NopLogger, got %T", beat.log)
+	}
+	beat.Start()
+	beat.Stop()
+
+	orig := defaultLogger
+	SetDefaultLogger(nil)
+	defer SetDefaultLogger(orig)
+
+	if _, ok := New().log.(NopLogger); !ok {
+		t.Error("expected NopLogger is the default logger")
+	}
+}
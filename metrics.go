@@ -0,0 +1,75 @@
+package beat
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// 调度器的执行计数，任务执行时更新
+type metrics struct {
+	executions atomic.Uint64 // 执行次数
+	errors     atomic.Uint64 // 返回错误的次数
+	panics     atomic.Uint64 // panic 的次数
+	goroutines atomic.Int64  // 尚未结束的任务协程数量
+	active     atomic.Int64  // 正在执行任务回调的协程数量
+}
+
+// 调度器指标的快照
+//
+// 计数器从创建调度器开始累计，不依赖任何监控库，可自行注册到 Prometheus 等采集器
+type Metrics struct {
+	Executions    uint64                   // 总执行次数，重试的任务每次触发只计为一次
+	Errors        uint64                   // 返回错误的总次数，不包括 panic
+	Panics        uint64                   // panic 的总次数
+	Goroutines    int64                    // 尚未结束的任务协程数量，包括等待信号量的协程
+	LastDurations map[string]time.Duration // 每个任务最近一次执行的耗时，未执行过的任务为 0
+}
+
+// 清空计数器，协程数量反映正在执行的任务，不清空
+func (m *metrics) reset() {
+	m.executions.Store(0)
+	m.errors.Store(0)
+	m.panics.Store(0)
+}
+
+// 获取所有任务最近一次执行的耗时
+func (b *Beat) lastDurations() map[string]time.Duration {
+	durations := make(map[string]time.Duration, len(b.jobs))
+	for _, job := range b.jobs {
+		durations[job.Id] = job.stats.snapshot().LastDuration
+	}
+
+	return durations
+}
+
+// 获取调度器指标的快照
+func (b *Beat) Metrics() Metrics {
+	// 先读取计数器再获取耗时，保证计数器中已结束的执行的耗时都已记录
+	metrics := Metrics{
+		Executions: b.metrics.executions.Load(),
+		Errors:     b.metrics.errors.Load(),
+		Panics:     b.metrics.panics.Load(),
+		Goroutines: b.metrics.goroutines.Load(),
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if !b.running {
+		metrics.LastDurations = b.lastDurations()
+	} else {
+		reply := make(chan map[string]time.Duration)
+		b.operate <- opLastDurations(reply)
+		metrics.LastDurations = <-reply
+	}
+
+	return metrics
+}
+
+// 获取正在执行的任务数量
+//
+// 只统计正在执行任务（包括重试间隔）的协程，不包括等待信号量或前一次执行结束的协程，
+// 不经过调度协程，可频繁调用
+func (b *Beat) ActiveJobs() int {
+	return int(b.metrics.active.Load())
+}
@@ -0,0 +1,97 @@
+package beat
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// Run a failing, a panicking and a succeeding job, expect the metrics count each of them.
+func TestMetrics(t *testing.T) {
+	beat := New(WithRecovery(), WithErrorHandler(func(string, error) {}), WithPanicHandler(func(string, any, []byte) {}))
+	beat.AddE("* 1 1 * 0 0 0", "TestMetrics-1",
+		func(ctx context.Context, userdata any) error { return errors.New("job failed") },
+		nil)
+	beat.Add("* 1 1 * 0 0 0", "TestMetrics-2",
+		func(ctx context.Context, userdata any) { panic("job panicked") },
+		nil)
+	beat.Add("* 1 1 * 0 0 0", "TestMetrics-3",
+		func(ctx context.Context, userdata any) { time.Sleep(10 * time.Millisecond) },
+		nil)
+
+	if metrics := beat.Metrics(); metrics.Executions != 0 || len(metrics.LastDurations) != 3 {
+		t.Errorf("expected empty metrics, got %+v", metrics)
+	}
+
+	beat.Start()
+	defer beat.Stop()
+
+	beat.RunNow("TestMetrics-1")
+	beat.RunNow("TestMetrics-2")
+	beat.RunNow("TestMetrics-3")
+
+	// Metrics are recorded after the job function returns.
+	deadline := time.Now().Add(OneSecond)
+	for {
+		metrics := beat.Metrics()
+		if metrics.Executions == 3 && metrics.Goroutines == 0 {
+			if metrics.Errors != 1 || metrics.Panics != 1 {
+				t.Errorf("unexpected metrics: %+v", metrics)
+			}
+			if metrics.LastDurations["TestMetrics-3"] < 10*time.Millisecond {
+				t.Errorf("expected last duration at least 10ms, got %v", metrics.LastDurations["TestMetrics-3"])
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected metrics are recorded, got %+v", metrics)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// Block jobs within the goroutine limit, expect the active jobs exclude the waiting ones.
+func TestActiveJobs(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{}, 3)
+
+	beat := New(WithMaxGoroutines(2))
+	for _, id := range []string{"TestActiveJobs-1", "TestActiveJobs-2", "TestActiveJobs-3"} {
+		beat.Add("* 1 1 * 0 0 0", id, func(ctx context.Context, userdata any) {
+			started <- struct{}{}
+			<-release
+		}, nil)
+	}
+
+	if beat.MaxGoroutines() != 2 {
+		t.Errorf("expected max goroutines 2, got %d", beat.MaxGoroutines())
+	}
+
+	beat.Start()
+	defer beat.Stop()
+
+	beat.RunNow("TestActiveJobs-1")
+	beat.RunNow("TestActiveJobs-2")
+	beat.RunNow("TestActiveJobs-3")
+	<-started
+	<-started
+
+	if active := beat.ActiveJobs(); active != 2 {
+		t.Errorf("expected 2 active jobs, got %d", active)
+	}
+	if max := beat.MaxGoroutines(); max != 2 {
+		t.Errorf("expected max goroutines 2, got %d", max)
+	}
+
+	close(release)
+	<-started
+
+	deadline := time.Now().Add(OneSecond)
+	for beat.ActiveJobs() != 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected no active jobs, got %d", beat.ActiveJobs())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
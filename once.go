@@ -0,0 +1,27 @@
+package beat
+
+import "time"
+
+// 添加在 delay 之后只运行一次的任务，运行后自动移除
+//
+//	delay: 从现在开始的延迟，按 WithLocation 指定的时区计算运行时间
+//	id: 任务ID，每个任务ID唯一
+//	fn: 任务执行回调
+//	userdata: 用于保存用户数据，回调时将传递该数据
+//	opts: 任务选项
+//
+// 未运行时从添加时开始计时，启动前运行时间已经过去则不会运行，可使用 WithAutoPrune 移除。
+// 任务的定时表达式为空，不会被 Save 保存；delay 不大于 0 时返回 ErrInvalidDelay
+func (b *Beat) AddOnce(delay time.Duration, id string, fn JobFunc, userdata any, opts ...JobOption) error {
+	if delay <= 0 {
+		return ErrInvalidDelay
+	}
+
+	at := b.clock.Now().In(b.Location()).Add(delay)
+
+	opts = append(opts[:len(opts):len(opts)], func(j *job) {
+		j.once = true
+	})
+
+	return b.AddSchedule(At(at), id, fn, userdata, opts...)
+}
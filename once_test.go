@@ -0,0 +1,62 @@
+package beat
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// Add a job running once after a delay, expect it fires at the delayed time in the beat's location and is removed.
+func TestAddOnce(t *testing.T) {
+	start := parseTime("2024-11-06T10:20:30+08:00")
+	loc := time.FixedZone("UTC-5", -5*60*60)
+	clock := newFakeClock(start)
+	fired := make(chan time.Time, 1)
+
+	beat := New(WithClock(clock), WithLocation(loc))
+	if err := beat.AddOnce(0, "TestAddOnce-1", nil, nil); !errors.Is(err, ErrInvalidDelay) {
+		t.Errorf("expected %v, got %v", ErrInvalidDelay, err)
+	}
+	if err := beat.AddOnce(time.Second, "", nil, nil); !errors.Is(err, ErrEmptyId) {
+		t.Errorf("expected %v, got %v", ErrEmptyId, err)
+	}
+
+	err := beat.AddOnce(5*time.Second, "TestAddOnce-1", func(ctx context.Context, userdata any) {
+		info, _ := JobInfoFromContext(ctx)
+		fired <- info.Scheduled
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entry, _ := beat.Entry("TestAddOnce-1")
+	if want := start.Add(5 * time.Second); !entry.Schedule.Next(start).Equal(want) {
+		t.Errorf("expected run time %v, got %v", want, entry.Schedule.Next(start))
+	}
+
+	beat.Start()
+	defer beat.Stop()
+
+	clock.BlockUntil(1)
+	clock.Advance(4 * time.Second)
+	select {
+	case <-fired:
+		t.Fatal("expected job does not fire before the delay")
+	case <-time.After(OneSecond / 10):
+	}
+
+	clock.Advance(time.Second)
+	select {
+	case scheduled := <-fired:
+		if want := start.Add(5 * time.Second).In(loc); !scheduled.Equal(want) || scheduled.Location() != loc {
+			t.Errorf("expected scheduled time %v, got %v", want, scheduled)
+		}
+	case <-time.After(OneSecond):
+		t.Fatal("expected job fires after the delay")
+	}
+
+	if beat.Contains("TestAddOnce-1") {
+		t.Error("expected job is removed after firing")
+	}
+}
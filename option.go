@@ -3,48 +3,111 @@ package beat
 import (
 	"context"
 	"time"
+
+	"golang.org/x/time/rate"
 )
 
 type option func(*Beat)
 
 // WithParser allows to specify custom parser.
+//
+// Any ScheduleParser can be used, such as a parser created by NewParser with WithSeconds or by NewRestrictedParser.
+// Default is DefaultParser, nil means DefaultParser.
 func WithParser(p ScheduleParser) option {
 	return func(b *Beat) {
+		if p == nil {
+			p = DefaultParser
+		}
 		b.parser = p
 	}
 }
 
-// WithRecover allows to enable panic recovery in job.
+// WithRecovery allows to enable panic recovery in job.
+//
+// Recovery is enabled by default, so it is only needed to undo WithoutRecovery.
 func WithRecovery() option {
 	return func(b *Beat) {
 		b.withRecovery = true
 	}
 }
 
+// WithoutRecovery allows to disable panic recovery in job.
+//
+// A panicking job then crashes the whole process, so it is intended for debugging only.
+func WithoutRecovery() option {
+	return func(b *Beat) {
+		b.withRecovery = false
+	}
+}
+
 // WithLocation allows to specify custom location.
+//
+// Default is time.Local, nil means time.Local.
 func WithLocation(location *time.Location) option {
 	return func(b *Beat) {
+		if location == nil {
+			location = time.Local
+		}
 		b.location = location
 	}
 }
 
+// WithClock allows to specify custom clock, which is useful for testing.
+//
+// nil means the system clock.
+func WithClock(clock Clock) option {
+	return func(b *Beat) {
+		if clock == nil {
+			clock = realClock{}
+		}
+		b.clock = clock
+	}
+}
+
+// WithNowFunc allows to specify a function returning the current time, which is a lighter alternative to WithClock for testing.
+//
+// Run times and logs are computed from the function, but timers still use the system clock: the beat sleeps for
+// the real duration until the next run time computed from the function, then reads the current time from the
+// function again, so jobs fire only if the function has reached their run times. It replaces the clock specified
+// by WithClock. nil means the system clock.
+func WithNowFunc(now func() time.Time) option {
+	return func(b *Beat) {
+		if now == nil {
+			b.clock = realClock{}
+			return
+		}
+		b.clock = nowFuncClock{now: now}
+	}
+}
+
 // WithLogger allows to specify custom logger.
+//
+// Use WithLogger(NopLogger{}) to silence all output, nil also means NopLogger.
 func WithLogger(log Logger) option {
 	return func(b *Beat) {
+		if log == nil {
+			log = NopLogger{}
+		}
 		b.log = log
 	}
 }
 
 // WithContext allows to specify custom context.
+//
+// Jobs receive a context derived from it, which is cancelled when the beat stops.
+// Default is context.Background(), nil means context.Background().
 func WithContext(ctx context.Context) option {
 	return func(b *Beat) {
+		if ctx == nil {
+			ctx = context.Background()
+		}
 		b.ctx = ctx
 	}
 }
 
 // WithMaxGoroutines allows to specify max number of goroutines.
 //
-// Default is 0. 0 means no limit
+// Default is 0. 0 means no limit, a negative number is treated as 0.
 func WithMaxGoroutines(max int) option {
 	return func(b *Beat) {
 		if max < 0 {
@@ -53,3 +116,176 @@ func WithMaxGoroutines(max int) option {
 		b.maxGoroutines = max
 	}
 }
+
+// WithRateLimit allows to limit the rate jobs start at, allowing bursts of up to burst jobs.
+//
+// It composes with WithMaxGoroutines: a job waits for a token in its own goroutine after acquiring the goroutine
+// limit, so the scheduler is never blocked. Jobs waiting for a token stop waiting when the beat stops.
+// A burst less than 1 is treated as 1. Default is no limit.
+func WithRateLimit(r rate.Limit, burst int) option {
+	return func(b *Beat) {
+		b.limiter = rate.NewLimiter(r, max(burst, 1))
+	}
+}
+
+// WithRejectDuplicates allows to reject adding a job whose id already exists.
+//
+// Default is to overwrite the old job.
+func WithRejectDuplicates() option {
+	return func(b *Beat) {
+		b.rejectDup = true
+	}
+}
+
+// WithErrorHandler allows to specify a handler for errors returned by jobs added with AddE.
+//
+// The handler is called in the job's goroutine. Default is to log the error.
+func WithErrorHandler(handler func(id string, err error)) option {
+	return func(b *Beat) {
+		b.errorHandler = handler
+	}
+}
+
+// WithPanicHandler allows to specify a handler for panics recovered from jobs.
+//
+// The handler is called in the job's goroutine with the recovered value and the stack.
+// It has no effect with WithoutRecovery. Default is to log the panic.
+func WithPanicHandler(handler func(id string, recovered any, stack []byte)) option {
+	return func(b *Beat) {
+		b.panicHandler = handler
+	}
+}
+
+// WithStackBufferSize allows to limit the size in bytes of the stack captured when a job panics.
+//
+// Default is 0, 0 means the full stack is captured.
+func WithStackBufferSize(size int) option {
+	return func(b *Beat) {
+		b.stackSize = max(size, 0)
+	}
+}
+
+// WithBeforeJob allows to specify a hook called in the job's goroutine before each execution.
+func WithBeforeJob(hook func(id string)) option {
+	return func(b *Beat) {
+		b.beforeJob = hook
+	}
+}
+
+// WithAfterJob allows to specify a hook called in the job's goroutine after each execution,
+// including when the job panics. dur is the execution duration.
+func WithAfterJob(hook func(id string, dur time.Duration)) option {
+	return func(b *Beat) {
+		b.afterJob = hook
+	}
+}
+
+// WithChain allows to specify wrappers applied to every job.
+//
+// The first wrapper is the outermost one. Wrappers are applied once when the job is added.
+func WithChain(wrappers ...JobWrapper) option {
+	return func(b *Beat) {
+		b.chain = wrappers
+	}
+}
+
+// WithEventBuffer allows to specify the buffer size of the event channel.
+//
+// Default is 64.
+func WithEventBuffer(size int) option {
+	return func(b *Beat) {
+		b.eventBuffer = max(size, 0)
+	}
+}
+
+// WithEventBlocking allows to block instead of dropping events when the event channel is full.
+//
+// A slow consumer then stalls the scheduler and the jobs.
+func WithEventBlocking() option {
+	return func(b *Beat) {
+		b.eventBlocking = true
+	}
+}
+
+// WithJitter allows to delay each execution of every job by a random duration in [0, max).
+//
+// The jitter is recomputed on every schedule and never reaches the following scheduled time.
+// WithJobJitter overrides it per job. Default is 0, 0 means no jitter.
+func WithJitter(max time.Duration) option {
+	return func(b *Beat) {
+		b.jitter = max
+	}
+}
+
+// WithCatchUp allows to run jobs once on start if they missed scheduled times while the beat was not running.
+//
+// Missed times are computed from the job's last run time, see WithLastRun. Multiple missed times
+// are collapsed into a single run, use WithCatchUpLimit to run more.
+func WithCatchUp() option {
+	return func(b *Beat) {
+		b.catchUp = max(b.catchUp, 1)
+	}
+}
+
+// WithCatchUpLimit allows to run jobs on start once for each missed scheduled time, up to limit times.
+//
+// It implies WithCatchUp. A limit less than 1 is treated as 1.
+func WithCatchUpLimit(limit int) option {
+	return func(b *Beat) {
+		b.catchUp = max(limit, 1)
+	}
+}
+
+// WithTryTimeout allows to specify how long TryAdd and TryRemove wait for the scheduler before returning ErrBusy.
+//
+// Default is 100ms. A timeout less than 0 is treated as 0, which fails immediately if the scheduler is busy.
+func WithTryTimeout(timeout time.Duration) option {
+	return func(b *Beat) {
+		b.tryTimeout = max(timeout, 0)
+	}
+}
+
+// WithOperateBuffer allows to specify the buffer size of the channel carrying requests to the running scheduler.
+//
+// With a buffer, requests without a result, such as Remove, return once queued instead of waiting for the
+// scheduler. Requests are still handled in order, and requests with a result still wait for it, except TryAdd,
+// which gives up a queued request once the timeout specified by WithTryTimeout elapses.
+// Default is 0. A size less than 0 is treated as 0.
+func WithOperateBuffer(size int) option {
+	return func(b *Beat) {
+		b.operateBuffer = max(size, 0)
+	}
+}
+
+// WithMinInterval allows to reject schedules firing more often than the interval.
+//
+// Adding or updating a job returns ErrTooFrequent if the gap between its next two run times from now is less
+// than the interval. Only the next two run times are checked. Default is 0, 0 means no limit.
+func WithMinInterval(interval time.Duration) option {
+	return func(b *Beat) {
+		b.minInterval = max(interval, 0)
+	}
+}
+
+// WithAutoRemoveFinished allows to remove jobs automatically once they will never run again.
+//
+// A job is finished when its next run time, recomputed after it fires, is zero. Schedules implementing
+// FiniteSchedule with Finite returning false, such as jobs added with AddAfter, are never removed.
+func WithAutoRemoveFinished() option {
+	return func(b *Beat) {
+		b.autoRemove = true
+	}
+}
+
+// WithAutoPrune allows to remove finished jobs whenever run times are recomputed, so dead jobs never accumulate.
+//
+// Unlike WithAutoRemoveFinished, which only checks jobs after they fire, it also removes jobs that are finished
+// once scheduled, such as a job added with At in the past or rescheduled to a time that never comes. Jobs are
+// checked when the beat starts, after they fire, and when they are added, updated, resumed or the location is
+// changed; queries never remove jobs. Finished jobs are determined in the same way as WithAutoRemoveFinished,
+// they are not removed while the beat is stopped.
+func WithAutoPrune() option {
+	return func(b *Beat) {
+		b.autoPrune = true
+	}
+}
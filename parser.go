@@ -2,6 +2,7 @@ package beat
 
 import (
 	"fmt"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -17,41 +18,96 @@ const (
 	Hour
 	Minute
 	Second
+
+	// 不是表达式的域，仅用于 WithFields，表示允许使用描述符
+	Descriptor
 )
 
+// 域的名称，用于错误信息
+func (f LayoutField) String() string {
+	switch f {
+	case Year:
+		return "year"
+	case Month:
+		return "month"
+	case Dom:
+		return "day"
+	case Dow:
+		return "weekday"
+	case Hour:
+		return "hour"
+	case Minute:
+		return "minute"
+	case Second:
+		return "second"
+	case Descriptor:
+		return "descriptor"
+	}
+
+	return fmt.Sprintf("LayoutField(%d)", uint32(f))
+}
+
 var DefaultLayout = []LayoutField{Year, Month, Dom, Dow, Hour, Minute, Second}
 
 type Parser struct {
 	layout         []LayoutField
 	defaultLoction *time.Location // 缺省时区，解析时未指定时区则以该参数时区解析
+	withSeconds    bool           // 是否启用秒域
+	noDescriptors  bool           // 是否禁止使用描述符
+	cacheSize      int            // 解析缓存的容量，0 表示不缓存
+	cache          *scheduleCache // 解析缓存
 }
 
 type SchedTime struct {
 	Year   [2]uint64 // 年
 	Month  uint64    // 月
 	Dom    uint64    // 日
-	Dow    uint64    // 星期，0=星期日
+	Dow    uint64    // 星期，0=星期日，解析时 7 也视为星期日
 	Hour   uint64    // 时
 	Minute uint64    // 分
 	Second uint64    // 秒
 
+	lastDays        uint64   // 月末的日，第 n 位表示倒数第 n 天（L-n），第 0 位表示最后一天（L）
+	nearestWeekdays uint64   // 离指定日最近的工作日，第 n 位表示离 n 日最近的工作日（nW）
+	lastWeekday     bool     // 每月最后一个工作日（LW）
+	nthWeekdays     [7]uint8 // 每月第 n 个星期几，nthWeekdays[d] 的第 n 位表示第 n 个星期 d（d#n）
+
 	location *time.Location
 }
 
-var defaultParser = NewParser()
+// 默认解析器，使用 DefaultLayout，New 创建的 Beat 默认使用该解析器
+//
+// 可直接用于校验表达式或创建 Schedule；需要不同配置时使用 NewParser 创建新的解析器，而不是修改该变量
+var DefaultParser = NewParser()
 
 func NewParser(opts ...parserOption) *Parser {
 	p := new(Parser)
 	p.layout = DefaultLayout
 	p.defaultLoction = time.Local
+	p.cacheSize = defaultCacheSize
 
 	for _, opt := range opts {
 		opt(p)
 	}
 
+	if p.cacheSize > 0 {
+		p.cache = newScheduleCache(p.cacheSize)
+	}
+
+	// 启用秒域但布局中没有秒域时，将秒域追加为最后一个域
+	if p.withSeconds && !slices.Contains(p.layout, Second) {
+		p.layout = append(slices.Clone(p.layout), Second)
+	}
+
 	return p
 }
 
+// 使用默认解析器校验时间表达式
+func Validate(expr string) error {
+	_, err := DefaultParser.Parse(expr)
+	return err
+}
+
 // 获取域的限制范围
 func (f LayoutField) bounds() (min, max int) {
 	switch f {
@@ -69,7 +125,7 @@ func (f LayoutField) bounds() (min, max int) {
 
 	case Dow:
 		min = 0
-		max = 6
+		max = 7 // 0 和 7 都表示星期日
 
 	case Hour:
 		min = 0
@@ -87,51 +143,142 @@ func (f LayoutField) bounds() (min, max int) {
 	return
 }
 
+// 描述符对应的表达式，按 DefaultLayout 排列
+var descriptors = map[string]string{
+	"@yearly":   "* 1 1 * 0 0 0",
+	"@annually": "* 1 1 * 0 0 0",
+	"@monthly":  "* * 1 * 0 0 0",
+	"@weekly":   "* * * 0 0 0 0",
+	"@daily":    "* * * * 0 0 0",
+	"@midnight": "* * * * 0 0 0",
+	"@hourly":   "* * * * * 0 0",
+}
+
 // 解析时间表达式
+//
+// 启用缓存时，相同的表达式不再重复解析，每次返回缓存结果的副本，修改返回的 SchedTime 不影响其他任务
 func (p *Parser) Parse(exp string) (Schedule, error) {
+	if p.cache == nil {
+		return p.parse(exp)
+	}
+
+	if sched, ok := p.cache.get(exp); ok {
+		return cloneSchedule(sched), nil
+	}
+
+	sched, err := p.parse(exp)
+	if err != nil {
+		return nil, err
+	}
+	p.cache.put(exp, cloneSchedule(sched))
+
+	return sched, nil
+}
+
+// 复制解析得到的 Schedule，SchedTime 的字段可被修改，不能在多个调用者间共享
+func cloneSchedule(sched Schedule) Schedule {
+	if st, ok := sched.(*SchedTime); ok {
+		clone := *st
+		return &clone
+	}
+
+	return sched
+}
+
+func (p *Parser) parse(exp string) (Schedule, error) {
 	fields := strings.Fields(exp)
 
-	if len(fields) < len(p.layout) {
+	if len(fields) == 0 {
 		return nil, fmt.Errorf("%w: invalid number of fields", ErrInvalidExp)
 	}
 
-	st := new(SchedTime)
-	st.location = p.defaultLoction
-	offset := 0
+	location, fields, err := parseLocation(fields, p.defaultLoction)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(fields) > 0 && strings.HasPrefix(fields[0], "@") {
+		if p.noDescriptors {
+			return nil, fmt.Errorf("%w: descriptors are not allowed: %s", ErrInvalidExp, fields[0])
+		}
+		return parseDescriptor(fields, location)
+	}
+
+	return parseFields(fields, p.layout, location)
+}
 
-	if loc, found := strings.CutPrefix(fields[0], "TZ="); found {
-		if len(fields)-1 < len(p.layout) {
-			return nil, fmt.Errorf("%w: invalid number of fields", ErrInvalidExp)
+// 解析 CRON_TZ= 或 TZ= 前缀指定的时区，返回时区和剩余的域
+//
+// 没有前缀时返回 location
+func parseLocation(fields []string, location *time.Location) (*time.Location, []string, error) {
+	for _, prefix := range []string{"CRON_TZ=", "TZ="} {
+		name, found := strings.CutPrefix(fields[0], prefix)
+		if !found {
+			continue
 		}
 
-		location, err := time.LoadLocation(loc)
+		loc, err := time.LoadLocation(name)
 		if err != nil {
-			return nil, fmt.Errorf("bad location '%s': %v", loc, err)
+			return nil, nil, fmt.Errorf("%w: bad location '%s': %v", ErrInvalidExp, name, err)
+		}
+
+		return loc, fields[1:], nil
+	}
+
+	return location, fields, nil
+}
+
+// 按照布局解析各个域
+//
+// 布局中没有的域视为通配，秒域除外（视为第 0 秒）
+func parseFields(fields []string, layout []LayoutField, location *time.Location) (*SchedTime, error) {
+	if len(fields) != len(layout) {
+		names := make([]string, 0, len(layout))
+		for _, lf := range layout {
+			names = append(names, lf.String())
 		}
 
-		st.location = location
-		offset = 1
+		return nil, fmt.Errorf("%w: invalid number of fields: expected %d (%s), got %d",
+			ErrInvalidExp, len(layout), strings.Join(names, " "), len(fields))
+	}
+
+	st := &SchedTime{
+		Year:     wildcard(Year),
+		Month:    wildcard(Month)[0],
+		Dom:      wildcard(Dom)[0],
+		Dow:      wildcard(Dow)[0],
+		Hour:     wildcard(Hour)[0],
+		Minute:   wildcard(Minute)[0],
+		Second:   1,
+		location: location,
 	}
 
-	for i := range p.layout {
-		bits, err := parseField(fields[i+offset], p.layout[i])
+	for i := range layout {
+		if layout[i] == Dom {
+			if err := parseDom(fields[i], st); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if layout[i] == Dow {
+			if err := parseDow(fields[i], st); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		bits, err := parseField(fields[i], layout[i])
 		if err != nil {
 			return nil, err
 		}
 
-		switch p.layout[i] {
+		switch layout[i] {
 		case Year:
 			st.Year = bits
 
 		case Month:
 			st.Month = bits[0]
 
-		case Dom:
-			st.Dom = bits[0]
-
-		case Dow:
-			st.Dow = bits[0]
-
 		case Hour:
 			st.Hour = bits[0]
 
@@ -143,12 +290,218 @@ func (p *Parser) Parse(exp string) (Schedule, error) {
 		}
 	}
 
+	if !st.isPossible() {
+		return nil, fmt.Errorf("%w: %w: no month has the specified day", ErrInvalidExp, ErrUnschedulable)
+	}
+
 	return st, nil
 }
 
+// 每个月份最多的天数，2 月按闰年计算
+var maxDaysInMonth = [13]int{0, 31, 29, 31, 30, 31, 30, 31, 31, 30, 31, 30, 31}
+
+// 判断“日”是否在某个指定的月份中存在，如 2 月 30 日不存在
+func (st *SchedTime) isPossible() bool {
+	if st.lastWeekday {
+		return true
+	}
+
+	for month := 1; month <= 12; month++ {
+		if (1<<month)&st.Month == 0 {
+			continue
+		}
+
+		days := maxDaysInMonth[month]
+		// 第 1 至 days 位有效
+		valid := uint64(1)<<(days+1) - 2
+		if (st.Dom|st.nearestWeekdays)&valid != 0 || st.lastDays&(valid>>1) != 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// 获取域通配时的有效位
+func wildcard(lf LayoutField) [2]uint64 {
+	bits, _ := parseField("*", lf)
+	return bits
+}
+
+// 解析描述符
+//
+// 支持：@every <duration>、@yearly、@annually、@monthly、@weekly、@daily、@midnight、@hourly
+func parseDescriptor(fields []string, location *time.Location) (Schedule, error) {
+	if fields[0] == "@every" {
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("%w: @every requires exactly one duration", ErrInvalidExp)
+		}
+
+		d, err := time.ParseDuration(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidExp, err)
+		}
+		if d <= 0 {
+			return nil, fmt.Errorf("%w: non-positive duration is not allowed: %s", ErrInvalidExp, fields[1])
+		}
+
+		return everySchedule{delay: d}, nil
+	}
+
+	expr, ok := descriptors[fields[0]]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown descriptor: %s", ErrInvalidExp, fields[0])
+	}
+	if len(fields) != 1 {
+		return nil, fmt.Errorf("%w: %s does not accept arguments", ErrInvalidExp, fields[0])
+	}
+
+	return parseFields(strings.Fields(expr), DefaultLayout, location)
+}
+
+// 月末的日最多可以往前偏移的天数
+const maxLastDayOffset = 30
+
+// 解析“日”域
+//
+// 除 parseField 支持的符号外，还支持 L（每月最后一天）、L-n（每月最后一天往前 n 天）、
+// nW（离 n 日最近的工作日）和 LW（每月最后一个工作日）。
+// L 和 W 不能与 - / 组合，但可以出现在列表中
+func parseDom(field string, st *SchedTime) error {
+	ranges := make([]string, 0)
+
+	for _, exp := range strings.Split(field, ",") {
+		switch {
+		case exp == "L":
+			st.lastDays |= 1
+
+		case exp == "LW":
+			st.lastWeekday = true
+
+		case strings.HasPrefix(exp, "L-"):
+			offset, err := strconv.Atoi(exp[2:])
+			if err != nil || offset < 1 || offset > maxLastDayOffset {
+				return fmt.Errorf("%w: invalid last day offset: %s", ErrInvalidExp, exp)
+			}
+			st.lastDays |= 1 << offset
+
+		case strings.HasSuffix(exp, "W"):
+			day, err := strconv.Atoi(exp[:len(exp)-1])
+			if min, max := Dom.bounds(); err != nil || day < min || day > max {
+				return fmt.Errorf("%w: invalid nearest weekday: %s", ErrInvalidExp, exp)
+			}
+			st.nearestWeekdays |= 1 << day
+
+		case strings.ContainsAny(exp, "LW"):
+			return fmt.Errorf("%w: L and W cannot be combined with other symbols: %s", ErrInvalidExp, exp)
+
+		default:
+			ranges = append(ranges, exp)
+		}
+	}
+
+	st.Dom = 0
+	if len(ranges) == 0 {
+		return nil
+	}
+
+	bits, err := parseField(strings.Join(ranges, ","), Dom)
+	if err != nil {
+		return err
+	}
+	st.Dom = bits[0]
+
+	return nil
+}
+
+// 每月中同一个星期几最多出现的次数
+const maxNthWeekday = 5
+
+// 解析“星期”域
+//
+// 除 parseField 支持的符号外，还支持 d#n（每月第 n 个星期 d），n 的范围为 1-5。
+// # 不能与 - / 组合，但可以出现在列表中
+func parseDow(field string, st *SchedTime) error {
+	ranges := make([]string, 0)
+
+	for _, exp := range strings.Split(field, ",") {
+		weekday, nth, found := strings.Cut(exp, "#")
+		if !found {
+			ranges = append(ranges, exp)
+			continue
+		}
+
+		d, err := parseValue(weekday, Dow)
+		if min, max := Dow.bounds(); err != nil || d < min || d > max {
+			return fmt.Errorf("%w: invalid weekday: %s", ErrInvalidExp, exp)
+		}
+
+		n, err := strconv.Atoi(nth)
+		if err != nil || n < 1 || n > maxNthWeekday {
+			return fmt.Errorf("%w: nth weekday must be within 1-%d: %s", ErrInvalidExp, maxNthWeekday, exp)
+		}
+
+		st.nthWeekdays[d%7] |= 1 << n
+	}
+
+	st.Dow = 0
+	if len(ranges) == 0 {
+		return nil
+	}
+
+	bits, err := parseField(strings.Join(ranges, ","), Dow)
+	if err != nil {
+		return err
+	}
+	st.Dow = bits[0]
+
+	return nil
+}
+
+// 月份和星期的英文缩写
+var (
+	monthNames = map[string]int{
+		"JAN": 1, "FEB": 2, "MAR": 3, "APR": 4, "MAY": 5, "JUN": 6,
+		"JUL": 7, "AUG": 8, "SEP": 9, "OCT": 10, "NOV": 11, "DEC": 12,
+	}
+	weekdayNames = map[string]int{
+		"SUN": 0, "MON": 1, "TUE": 2, "WED": 3, "THU": 4, "FRI": 5, "SAT": 6,
+	}
+)
+
+// 解析域中的值
+//
+// 月份和星期除数字外，还支持不区分大小写的英文缩写，如 JAN、MON
+func parseValue(value string, lf LayoutField) (int, error) {
+	var names map[string]int
+	switch lf {
+	case Month:
+		names = monthNames
+	case Dow:
+		names = weekdayNames
+	}
+
+	if n, ok := names[strings.ToUpper(value)]; ok {
+		return n, nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		if names != nil {
+			return 0, fmt.Errorf("%w: unknown name: %s", ErrInvalidExp, value)
+		}
+		return 0, fmt.Errorf("%w: %s", ErrInvalidExp, err)
+	}
+
+	return n, nil
+}
+
 // 解析域
 //
 // 支持符号：, - * /
+//
+// 步长从范围起始值开始计算，如 0-30/5 为 0, 5, ..., 30；只有起始值时范围到最大值为止；
+// 步长大于范围时只有起始值有效
 func parseField(field string, lf LayoutField) ([2]uint64, error) {
 	ranges := strings.Split(field, ",")
 	min, max := lf.bounds()
@@ -172,9 +525,9 @@ func parseField(field string, lf LayoutField) ([2]uint64, error) {
 			end = max
 		} else {
 			// 首个字符不是通配符，说明表达式中至少标明了起始值，尝试转换为整型
-			start, err = strconv.Atoi(lowAndHigh[0])
+			start, err = parseValue(lowAndHigh[0], lf)
 			if err != nil {
-				return [2]uint64{}, fmt.Errorf("%w: %s", ErrInvalidExp, err)
+				return [2]uint64{}, err
 			}
 
 			switch len(lowAndHigh) {
@@ -182,9 +535,13 @@ func parseField(field string, lf LayoutField) ([2]uint64, error) {
 				end = start
 
 			case 2: // 长度为2，说明表达式中标明了结束值
-				end, err = strconv.Atoi(lowAndHigh[1])
+				end, err = parseValue(lowAndHigh[1], lf)
 				if err != nil {
-					return [2]uint64{}, fmt.Errorf("%w: %s", ErrInvalidExp, err)
+					return [2]uint64{}, err
+				}
+				// 作为范围结束值时 SUN 表示 7，使 FRI-SUN 等范围有效
+				if lf == Dow && strings.EqualFold(lowAndHigh[1], "SUN") {
+					end = 7
 				}
 
 			default: // 语法错误
@@ -234,14 +591,26 @@ func parseField(field string, lf LayoutField) ([2]uint64, error) {
 		}
 	}
 
+	// 星期域中 7 与 0 相同，都表示星期日
+	if lf == Dow && bits[0]&(1<<7) != 0 {
+		bits[0] = bits[0]&^(1<<7) | 1
+	}
+
 	return bits, nil
 }
 
+// 查找下一个有效时间时最多向后查找的年数
+//
+// 同时指定日和星期时（如 2 月 29 日且为星期一），最长 28 年才会出现一次
+const maxSearchYears = 28
+
 // 获取下一个有效时间
+//
+// 在 maxSearchYears 年内没有有效时间时返回零值时间。
+// 夏令时切换时，指定了小时的定时按以下规则处理（小时通配的定时不受影响）：
+// 切换时跳过的时间（如 2:30）在跳过的时间段结束时（如 3:00）运行一次；
+// 切换时重复的时间（如 1:30）只在第一次出现时运行
 func (st *SchedTime) Next(t time.Time) time.Time {
-	// 检查时间域是否匹配，如果匹配，则进行下一个域的匹配。
-	// 如果域不匹配，则增加该域的值。
-
 	// 如果指定了时区，则将给定时间转换为 SchedTime 的时区。
 	// 保存原始时区，以便找到时间后再转换回来。
 	// 请注意，未指定时区的 SchedTime 将被视为本地时区。
@@ -254,17 +623,26 @@ func (st *SchedTime) Next(t time.Time) time.Time {
 		t = t.In(st.location)
 	}
 
-	// 匹配机制未匹配到时，将一直增加时间进行匹配，
-	// 此值用于限制匹配失败的上限
-	yearMax := t.Year() + 2
+	for {
+		t = st.next(t, loc)
+		if t.IsZero() {
+			return time.Time{}
+		}
 
-	// 设定用于年份判断的位
-	yearBits := st.Year[0]
-	delta := t.Year() - 1970
-	if delta >= 64 {
-		yearBits = st.Year[1]
-		delta = delta - 64
+		if !st.isRepeated(t) {
+			return t.In(origLocation)
+		}
 	}
+}
+
+// 在 loc 时区中获取下一个有效时间，不处理重复的时间
+func (st *SchedTime) next(t time.Time, loc *time.Location) time.Time {
+	// 检查时间域是否匹配，如果匹配，则进行下一个域的匹配。
+	// 如果域不匹配，则增加该域的值。
+
+	// 匹配机制未匹配到时，将一直增加时间进行匹配，
+	// 此值用于限制匹配失败的上限
+	yearMax := t.Year() + maxSearchYears
 
 	// 防止以下情况的出现：因时间精度问题，10.001 秒的时候进入该方法，
 	// 如果直接进行匹配，则第 10 秒的时间就会忽略
@@ -277,12 +655,9 @@ LOOP:
 		return time.Time{}
 	}
 
-	for (1<<delta)&yearBits == 0 {
-		if !added {
-			added = true
-			t = time.Date(t.Year(), time.January, 1, 0, 0, 0, 0, loc)
-		}
-		t = t.AddDate(1, 0, 0)
+	for !isYearMatch(st, t.Year()) {
+		added = true
+		t = startOfDay(t.Year()+1, time.January, 1, loc)
 
 		if t.Year() > yearMax {
 			return time.Time{}
@@ -290,40 +665,47 @@ LOOP:
 	}
 
 	for (1<<t.Month())&st.Month == 0 {
-		if !added {
-			added = true
-			t = time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, loc)
-		}
-		t = t.AddDate(0, 1, 0)
+		added = true
+		t = startOfDay(t.Year(), t.Month()+1, 1, loc)
 
 		if t.Month() == time.January {
 			goto LOOP
 		}
 	}
 
-	// 不支持 DST（夏令时）
 	for !isDayMatch(st, t) {
-		if !added {
-			added = true
-			t = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
-		}
-		t = t.AddDate(0, 0, 1)
+		added = true
+		t = startOfDay(t.Year(), t.Month(), t.Day()+1, loc)
 
 		if t.Day() == 1 {
 			goto LOOP
 		}
 	}
 
+	// 夏令时在 0 点切换时，当天从切换后的时刻开始，被跳过的小时在该时刻运行
+	if t.Equal(startOfDay(t.Year(), t.Month(), t.Day(), loc)) && st.isSkipped(t.Add(-time.Hour), t) {
+		return t
+	}
+
 	for (1<<t.Hour())&st.Hour == 0 {
 		if !added {
 			added = true
 			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, loc)
 		}
+		prev := t
 		t = t.Add(time.Hour)
 
-		if t.Hour() == 0 {
+		// 小时连续时没有跨越日期，也没有因夏令时跳过时间
+		if hour := t.Hour(); hour != 0 && hour == prev.Hour()+1 {
+			continue
+		}
+		// 进入新的一天时需要重新匹配日期
+		if t.Day() != prev.Day() {
 			goto LOOP
 		}
+		if st.isSkipped(prev, t) {
+			return t
+		}
 	}
 
 	for (1<<t.Minute())&st.Minute == 0 {
@@ -331,8 +713,16 @@ LOOP:
 			added = true
 			t = t.Truncate(time.Minute)
 		}
+		prev := t
 		t = t.Add(time.Minute)
 
+		// 分钟连续时没有跨越整点，也没有因夏令时跳过时间
+		if minute := t.Minute(); minute != 0 && minute == prev.Minute()+1 {
+			continue
+		}
+		if t.Day() == prev.Day() && st.isSkipped(prev, t) {
+			return t
+		}
 		if t.Minute() == 0 {
 			goto LOOP
 		}
@@ -343,20 +733,149 @@ LOOP:
 			added = true
 			t = t.Truncate(time.Second)
 		}
+		prev := t
 		t = t.Add(time.Second)
 
+		// 秒连续时没有跨越整分，也没有因夏令时跳过时间
+		if second := t.Second(); second != 0 && second == prev.Second()+1 {
+			continue
+		}
+		if t.Day() == prev.Day() && st.isSkipped(prev, t) {
+			return t
+		}
 		if t.Second() == 0 {
 			goto LOOP
 		}
 	}
 
-	return t.In(origLocation)
+	return t
+}
+
+// 小时通配时的有效位，第 0 至 23 位有效
+const allHours = 1<<24 - 1
+
+// 判断小时是否为通配
+func (st *SchedTime) isHourWildcard() bool {
+	return st.Hour == allHours
+}
+
+// 获取指定日期在 loc 中的第一个时刻
+//
+// 夏令时在 0 点切换时当天没有 0 点，time.Date 返回前一天的时间，此时返回切换后的第一个时刻
+func startOfDay(year int, month time.Month, day int, loc *time.Location) time.Time {
+	t := time.Date(year, month, day, 0, 0, 0, 0, loc)
+	if t.Hour() != 0 {
+		if _, end := t.ZoneBounds(); end.After(t) {
+			return end
+		}
+	}
+
+	return t
+}
+
+// 判断从 prev 到 t 是否因夏令时跳过了匹配的小时
+func (st *SchedTime) isSkipped(prev, t time.Time) bool {
+	if st.isHourWildcard() {
+		return false
+	}
+
+	steps := (t.Hour() - prev.Hour() + 24) % 24
+	for i := 1; i < steps; i++ {
+		if (1<<((prev.Hour()+i)%24))&st.Hour != 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// 判断 t 是否为夏令时结束时第二次出现的时间
+func (st *SchedTime) isRepeated(t time.Time) bool {
+	if st.isHourWildcard() {
+		return false
+	}
+
+	_, offset := t.Zone()
+	_, prevOffset := t.Add(-24 * time.Hour).Zone()
+	if prevOffset <= offset {
+		return false
+	}
+
+	// 按切换前的偏移，同样的墙上时间出现在更早的时刻
+	earlier := t.Add(-time.Duration(prevOffset-offset) * time.Second)
+	if _, off := earlier.Zone(); off != prevOffset {
+		return false
+	}
+
+	return earlier.Hour() == t.Hour() && earlier.Minute() == t.Minute() && earlier.Second() == t.Second()
+}
+
+// 判断年份是否匹配
+func isYearMatch(st *SchedTime, year int) bool {
+	delta := year - 1970
+	switch {
+	case delta < 0 || delta >= 128:
+		return false
+	case delta < 64:
+		return (1<<delta)&st.Year[0] != 0
+	default:
+		return (1<<(delta-64))&st.Year[1] != 0
+	}
 }
 
 // 判断“日”是否匹配，匹配规则为：必须“日”和“星期”都匹配，则认为匹配
 func isDayMatch(st *SchedTime, t time.Time) bool {
-	domMatch := ((1 << t.Day()) & st.Dom) != 0
-	dowMatch := ((1 << t.Weekday()) & st.Dow) != 0
+	domMatch := ((1<<t.Day())&st.Dom) != 0 ||
+		((1<<(daysIn(t)-t.Day()))&st.lastDays) != 0 ||
+		isWeekdayMatch(st, t)
+	dowMatch := ((1<<t.Weekday())&st.Dow) != 0 ||
+		((1<<((t.Day()-1)/7+1))&st.nthWeekdays[t.Weekday()]) != 0
 
 	return domMatch && dowMatch
 }
+
+// 判断 t 是否为 nW 或 LW 指定的工作日
+func isWeekdayMatch(st *SchedTime, t time.Time) bool {
+	if st.nearestWeekdays == 0 && !st.lastWeekday {
+		return false
+	}
+
+	last := daysIn(t)
+	if st.lastWeekday && nearestWeekday(t, last) == t.Day() {
+		return true
+	}
+
+	for day := 1; day <= last; day++ {
+		if (1<<day)&st.nearestWeekdays != 0 && nearestWeekday(t, day) == t.Day() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// 获取 t 所在月份中离 day 日最近的工作日，不会跨越月份
+func nearestWeekday(t time.Time, day int) int {
+	last := daysIn(t)
+
+	switch time.Date(t.Year(), t.Month(), day, 0, 0, 0, 0, time.UTC).Weekday() {
+	case time.Saturday:
+		if day == 1 {
+			return day + 2
+		}
+		return day - 1
+
+	case time.Sunday:
+		if day == last {
+			return day - 2
+		}
+		return day + 1
+	}
+
+	return day
+}
+
+// 获取 t 所在月份的天数
+func daysIn(t time.Time) int {
+	return time.Date(t.Year(), t.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
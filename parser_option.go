@@ -17,3 +17,39 @@ func WithDefaultLocation(location *time.Location) parserOption {
 		p.defaultLoction = location
 	}
 }
+
+// WithFields allows to specify the fields present in expressions as a combination of LayoutField,
+// such as Minute|Hour|Dom|Month|Dow.
+//
+// Fields are laid out in the order of DefaultLayout regardless of the order combined. Descriptors such as
+// @daily and @every are accepted only if Descriptor is included. It replaces the layout specified by WithLayout.
+func WithFields(fields LayoutField) parserOption {
+	return func(p *Parser) {
+		p.layout = make([]LayoutField, 0, len(DefaultLayout))
+		for _, lf := range DefaultLayout {
+			if fields&lf != 0 {
+				p.layout = append(p.layout, lf)
+			}
+		}
+		p.noDescriptors = fields&Descriptor == 0
+	}
+}
+
+// WithSeconds allows to enable the second field, it is appended to the layout as the last field if absent.
+//
+// DefaultLayout already contains the second field. Without the second field, jobs fire at second 0.
+func WithSeconds() parserOption {
+	return func(p *Parser) {
+		p.withSeconds = true
+	}
+}
+
+// WithCacheSize allows to specify the number of parsed expressions kept in the cache.
+//
+// Identical expressions are not parsed again, each Parse returns a copy of the cached schedule,
+// so modifying a returned SchedTime affects neither the cache nor other jobs. Default is 256, 0 disables the cache.
+func WithCacheSize(size int) parserOption {
+	return func(p *Parser) {
+		p.cacheSize = max(size, 0)
+	}
+}
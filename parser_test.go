@@ -1,7 +1,10 @@
 package beat
 
 import (
+	"errors"
 	"fmt"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 )
@@ -37,7 +40,7 @@ func TestActivation(t *testing.T) {
 	}
 
 	for _, test := range tests {
-		sched, err := defaultParser.Parse(test.spec)
+		sched, err := DefaultParser.Parse(test.spec)
 		if err != nil {
 			t.Error(err)
 			continue
@@ -75,7 +78,7 @@ func TestDomAndDow(t *testing.T) {
 		"2024-12-25T00:00:00+08:00",
 		"2025-01-01T00:00:00+08:00",
 	}
-	sched1, err := defaultParser.Parse(expr1)
+	sched1, err := DefaultParser.Parse(expr1)
 	if err != nil {
 		panic(err)
 	}
@@ -101,7 +104,7 @@ func TestDomAndDow(t *testing.T) {
 		"2024-12-04T00:00:00+08:00",
 		"2024-12-11T00:00:00+08:00",
 	}
-	sched2, err := defaultParser.Parse(expr2)
+	sched2, err := DefaultParser.Parse(expr2)
 	if err != nil {
 		panic(err)
 	}
@@ -160,7 +163,7 @@ func TestParserTimeZone(t *testing.T) {
 	}
 
 	for _, test := range tests {
-		sched, err := defaultParser.Parse(test.spec)
+		sched, err := DefaultParser.Parse(test.spec)
 		if err != nil {
 			t.Error(err)
 			continue
@@ -173,3 +176,768 @@ func TestParserTimeZone(t *testing.T) {
 		}
 	}
 }
+
+func TestParseEvery(t *testing.T) {
+	now := parseTime("2024-11-06T00:00:00+08:00")
+
+	tests := []struct {
+		spec     string
+		expected time.Duration
+	}{
+		{"@every 30s", 30 * time.Second},
+		{"@every 1h30m", 90 * time.Minute},
+		{"@every 500ms", 500 * time.Millisecond},
+	}
+
+	for _, test := range tests {
+		sched, err := DefaultParser.Parse(test.spec)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		actual := sched.Next(now)
+		if expected := now.Add(test.expected); actual != expected {
+			t.Errorf("Fail evaluating %s on %s: (expected) %s != %s (actual)",
+				test.spec, now, expected, actual)
+		}
+	}
+
+	for _, spec := range []string{"@every", "@every 0s", "@every -1m", "@every 1x", "@every 1s 2s"} {
+		if _, err := DefaultParser.Parse(spec); !errors.Is(err, ErrInvalidExp) {
+			t.Errorf("expected %q is invalid, got %v", spec, err)
+		}
+	}
+}
+
+func TestParseDescriptors(t *testing.T) {
+	start := parseTime("2024-11-06T10:20:30+08:00")
+
+	tests := []struct {
+		spec     string
+		expected string
+	}{
+		{"@yearly", "2025-01-01T00:00:00+08:00"},
+		{"@annually", "2025-01-01T00:00:00+08:00"},
+		{"@monthly", "2024-12-01T00:00:00+08:00"},
+		{"@weekly", "2024-11-10T00:00:00+08:00"},
+		{"@daily", "2024-11-07T00:00:00+08:00"},
+		{"@midnight", "2024-11-07T00:00:00+08:00"},
+		{"@hourly", "2024-11-06T11:00:00+08:00"},
+		{"TZ=UTC @daily", "2024-11-07T08:00:00+08:00"},
+		{"CRON_TZ=UTC @daily", "2024-11-07T08:00:00+08:00"},
+		{"CRON_TZ=America/New_York @daily", "2024-11-06T13:00:00+08:00"},
+	}
+
+	for _, test := range tests {
+		sched, err := DefaultParser.Parse(test.spec)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		actual := sched.Next(start)
+		if expected := parseTime(test.expected); !actual.Equal(expected) {
+			t.Errorf("Fail evaluating %s on %s: (expected) %s != %s (actual)",
+				test.spec, start, expected, actual)
+		}
+	}
+
+	for _, spec := range []string{"@fortnightly", "@daily 1", "TZ=UTC @unknown", "CRON_TZ=Mars/Olympus @daily", "TZ=Nowhere * * * * * * *"} {
+		_, err := DefaultParser.Parse(spec)
+		if !errors.Is(err, ErrInvalidExp) {
+			t.Errorf("expected %q is invalid, got %v", spec, err)
+		}
+	}
+}
+
+func TestParserWithSeconds(t *testing.T) {
+	layout := []LayoutField{Month, Dom, Dow, Hour, Minute}
+	start := parseTime("2024-11-06T10:20:30+08:00")
+
+	// Without the second field, jobs fire at second 0.
+	sched, err := NewParser(WithLayout(layout)).Parse("* * * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual, expected := sched.Next(start), parseTime("2024-11-06T10:21:00+08:00"); !actual.Equal(expected) {
+		t.Errorf("(expected) %s != %s (actual)", expected, actual)
+	}
+
+	p := NewParser(WithSeconds(), WithLayout(layout))
+
+	sched, err = p.Parse("* * * * * */10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual, expected := sched.Next(start), parseTime("2024-11-06T10:20:40+08:00"); !actual.Equal(expected) {
+		t.Errorf("(expected) %s != %s (actual)", expected, actual)
+	}
+
+	if _, err := p.Parse("* * * * *"); !errors.Is(err, ErrInvalidExp) {
+		t.Errorf("expected 5 fields are invalid in seconds mode, got %v", err)
+	}
+}
+
+// Build parsers from field combinations, expect the fields in the default order and descriptors only when enabled.
+func TestParserWithFields(t *testing.T) {
+	start := parseTime("2024-11-06T10:20:30+08:00")
+
+	p := NewParser(WithFields(Dow | Minute | Hour | Dom | Month))
+	sched, err := p.Parse("* * * 9 30")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual, expected := sched.Next(start), parseTime("2024-11-07T09:30:00+08:00"); !actual.Equal(expected) {
+		t.Errorf("(expected) %s != %s (actual)", expected, actual)
+	}
+
+	if _, err := p.Parse("@daily"); !errors.Is(err, ErrInvalidExp) {
+		t.Errorf("expected descriptors are not allowed, got %v", err)
+	}
+	_, err = p.Parse("* * * * 9 30 0")
+	if !errors.Is(err, ErrInvalidExp) || !strings.Contains(err.Error(), "expected 5 (month day weekday hour minute), got 7") {
+		t.Errorf("expected a clear field count error, got %v", err)
+	}
+
+	p = NewParser(WithFields(Hour | Minute | Second | Descriptor))
+	if _, err := p.Parse("@every 1h"); err != nil {
+		t.Errorf("expected descriptors are allowed, got %v", err)
+	}
+	sched, err = p.Parse("10 30 15")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual, expected := sched.Next(start), parseTime("2024-11-06T10:30:15+08:00"); !actual.Equal(expected) {
+		t.Errorf("(expected) %s != %s (actual)", expected, actual)
+	}
+}
+
+// parserFunc adapts a function to ScheduleParser.
+type parserFunc func(expr string) (Schedule, error)
+
+func (f parserFunc) Parse(expr string) (Schedule, error) {
+	return f(expr)
+}
+
+// Add jobs with a custom parser, expect every expression goes through it.
+func TestWithParser(t *testing.T) {
+	var parsed []string
+	parser := parserFunc(func(expr string) (Schedule, error) {
+		parsed = append(parsed, expr)
+		if expr == "hourly" {
+			return Every(time.Hour), nil
+		}
+		return nil, ErrInvalidExp
+	})
+
+	beat := New(WithParser(parser))
+	if err := beat.Add("hourly", "TestWithParser-1", nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := beat.Add("* * * * * * *", "TestWithParser-2", nil, nil); !errors.Is(err, ErrInvalidExp) {
+		t.Errorf("expected %v, got %v", ErrInvalidExp, err)
+	}
+	if !slices.Equal(parsed, []string{"hourly", "* * * * * * *"}) {
+		t.Errorf("unexpected parsed expressions %v", parsed)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := Validate("* * * * * * *"); err != nil {
+		t.Error(err)
+	}
+	if err := Validate("* * * * * 60 *"); !errors.Is(err, ErrInvalidExp) {
+		t.Errorf("expected invalid expression, got %v", err)
+	}
+
+	beat := New(WithParser(NewParser(WithLayout([]LayoutField{Hour, Minute}))))
+	if err := beat.Validate("1 2"); err != nil {
+		t.Error(err)
+	}
+	if err := beat.Validate("* * * * * * *"); !errors.Is(err, ErrInvalidExp) {
+		t.Errorf("expected invalid expression, got %v", err)
+	}
+	if n := len(beat.Entries()); n != 0 {
+		t.Errorf("expected no job is added, got %d", n)
+	}
+}
+
+func TestDaylightSaving(t *testing.T) {
+	tests := []struct {
+		location string
+		spec     string
+		start    string
+		expected []string
+	}{
+		// US spring forward: 2:00 EST jumps to 3:00 EDT.
+		{"America/New_York", "* * * * 2 30 0", "2024-03-10T00:00:00-05:00", []string{
+			"2024-03-10T03:00:00-04:00",
+			"2024-03-11T02:30:00-04:00",
+		}},
+		// US fall back: 2:00 EDT falls back to 1:00 EST.
+		{"America/New_York", "* * * * 1 30 0", "2024-11-03T00:00:00-04:00", []string{
+			"2024-11-03T01:30:00-04:00",
+			"2024-11-04T01:30:00-05:00",
+		}},
+		// Jobs with a wildcard hour fire in every hour, including the repeated one.
+		{"America/New_York", "* * * * * 30 0", "2024-11-03T01:00:00-04:00", []string{
+			"2024-11-03T01:30:00-04:00",
+			"2024-11-03T01:30:00-05:00",
+			"2024-11-03T02:30:00-05:00",
+		}},
+		{"America/New_York", "* * * * * 30 0", "2024-03-10T01:00:00-05:00", []string{
+			"2024-03-10T01:30:00-05:00",
+			"2024-03-10T03:30:00-04:00",
+		}},
+		// EU spring forward: 2:00 CET jumps to 3:00 CEST.
+		{"Europe/Berlin", "* * * * 2 15 0", "2024-03-31T00:00:00+01:00", []string{
+			"2024-03-31T03:00:00+02:00",
+			"2024-04-01T02:15:00+02:00",
+		}},
+		{"Europe/Berlin", "* * * * 1-3 0 0", "2024-03-31T00:30:00+01:00", []string{
+			"2024-03-31T01:00:00+01:00",
+			"2024-03-31T03:00:00+02:00",
+			"2024-04-01T01:00:00+02:00",
+		}},
+		// EU fall back: 3:00 CEST falls back to 2:00 CET.
+		{"Europe/Berlin", "* * * * 2 15 0", "2024-10-27T00:00:00+02:00", []string{
+			"2024-10-27T02:15:00+02:00",
+			"2024-10-28T02:15:00+01:00",
+		}},
+		// Chile spring forward at midnight: 0:00 -04 jumps to 1:00 -03.
+		{"America/Santiago", "* * 7 * 0 0 0", "2024-09-07T12:00:00-04:00", []string{
+			"2024-10-07T00:00:00-03:00",
+		}},
+		{"America/Santiago", "* * 8 * 0 0 0", "2024-09-07T12:00:00-04:00", []string{
+			"2024-09-08T01:00:00-03:00",
+			"2024-10-08T00:00:00-03:00",
+		}},
+		{"America/Santiago", "* * 9 * 0 0 0", "2024-09-07T12:00:00-04:00", []string{
+			"2024-09-09T00:00:00-03:00",
+			"2024-10-09T00:00:00-03:00",
+		}},
+		{"America/Santiago", "* * * * 0 30 0", "2024-09-07T12:00:00-04:00", []string{
+			"2024-09-08T01:00:00-03:00",
+			"2024-09-09T00:30:00-03:00",
+		}},
+		// Cuba spring forward at midnight: 0:00 CST jumps to 1:00 CDT.
+		{"America/Havana", "* * * * 0 0 0", "2024-03-09T12:00:00-05:00", []string{
+			"2024-03-10T01:00:00-04:00",
+			"2024-03-11T00:00:00-04:00",
+		}},
+		{"America/Havana", "* * 11 * 0 0 0", "2024-03-09T12:00:00-05:00", []string{
+			"2024-03-11T00:00:00-04:00",
+		}},
+		// Cuba fall back at 1:00: 1:00 CDT falls back to 0:00 CST.
+		{"America/Havana", "* * * * 0 30 0", "2024-11-02T12:00:00-04:00", []string{
+			"2024-11-03T00:30:00-04:00",
+			"2024-11-04T00:30:00-05:00",
+		}},
+	}
+
+	for _, test := range tests {
+		loc, err := time.LoadLocation(test.location)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		sched, err := NewParser(WithDefaultLocation(loc)).Parse(test.spec)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		next := parseTime(test.start).In(loc)
+		for _, item := range test.expected {
+			actual := sched.Next(next)
+			if expected := parseTime(item); !actual.Equal(expected) {
+				t.Errorf("Fail evaluating %s in %s on %s: (expected) %s != %s (actual)",
+					test.spec, test.location, next, expected, actual)
+			}
+			next = actual
+		}
+	}
+}
+
+func TestLastDayOfMonth(t *testing.T) {
+	tests := []struct {
+		spec     string
+		start    string
+		expected []string
+	}{
+		{"* * L * 0 0 0", "2024-01-15T00:00:00+08:00", []string{
+			"2024-01-31T00:00:00+08:00",
+			"2024-02-29T00:00:00+08:00",
+			"2024-03-31T00:00:00+08:00",
+			"2024-04-30T00:00:00+08:00",
+		}},
+		{"* * L-3 * 0 0 0", "2023-02-01T00:00:00+08:00", []string{
+			"2023-02-25T00:00:00+08:00",
+			"2023-03-28T00:00:00+08:00",
+			"2023-04-27T00:00:00+08:00",
+		}},
+		{"* * 1,L * 0 0 0", "2024-02-15T00:00:00+08:00", []string{
+			"2024-02-29T00:00:00+08:00",
+			"2024-03-01T00:00:00+08:00",
+			"2024-03-31T00:00:00+08:00",
+		}},
+	}
+
+	for _, test := range tests {
+		sched, err := DefaultParser.Parse(test.spec)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		next := parseTime(test.start)
+		for _, item := range test.expected {
+			actual := sched.Next(next)
+			if expected := parseTime(item); !actual.Equal(expected) {
+				t.Errorf("Fail evaluating %s on %s: (expected) %s != %s (actual)",
+					test.spec, next, expected, actual)
+			}
+			next = actual
+		}
+	}
+
+	for _, spec := range []string{"* * L/2 * 0 0 0", "* * 1-L * 0 0 0", "* * L-0 * 0 0 0", "* * L-31 * 0 0 0", "* * L-x * 0 0 0", "* * LX * 0 0 0"} {
+		if _, err := DefaultParser.Parse(spec); !errors.Is(err, ErrInvalidExp) {
+			t.Errorf("expected %q is invalid, got %v", spec, err)
+		}
+	}
+}
+
+func TestNearestWeekday(t *testing.T) {
+	tests := []struct {
+		spec     string
+		start    string
+		expected []string
+	}{
+		{"* * 15W * 0 0 0", "2024-06-01T00:00:00+08:00", []string{
+			"2024-06-14T00:00:00+08:00", // Saturday, moves back to Friday
+			"2024-07-15T00:00:00+08:00",
+			"2024-08-15T00:00:00+08:00",
+			"2024-09-16T00:00:00+08:00", // Sunday, moves forward to Monday
+		}},
+		// The 1st on a weekend never moves back to the previous month.
+		{"* * 1W * 0 0 0", "2024-05-15T00:00:00+08:00", []string{
+			"2024-06-03T00:00:00+08:00", // Saturday, moves forward to Monday
+			"2024-07-01T00:00:00+08:00",
+			"2024-08-01T00:00:00+08:00",
+			"2024-09-02T00:00:00+08:00", // Sunday, moves forward to Monday
+		}},
+		// The last day on a weekend never moves forward to the next month.
+		{"* * LW * 0 0 0", "2024-03-01T00:00:00+08:00", []string{
+			"2024-03-29T00:00:00+08:00", // Sunday, moves back to Friday
+			"2024-04-30T00:00:00+08:00",
+			"2024-05-31T00:00:00+08:00",
+			"2024-06-28T00:00:00+08:00",
+		}},
+		{"* * 31W * 0 0 0", "2024-04-01T00:00:00+08:00", []string{
+			"2024-05-31T00:00:00+08:00",
+		}},
+	}
+
+	for _, test := range tests {
+		sched, err := DefaultParser.Parse(test.spec)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		next := parseTime(test.start)
+		for _, item := range test.expected {
+			actual := sched.Next(next)
+			if expected := parseTime(item); !actual.Equal(expected) {
+				t.Errorf("Fail evaluating %s on %s: (expected) %s != %s (actual)",
+					test.spec, next, expected, actual)
+			}
+			next = actual
+		}
+	}
+
+	for _, spec := range []string{"* * W * 0 0 0", "* * 0W * 0 0 0", "* * 32W * 0 0 0", "* * 1-5W * 0 0 0", "* * 5W/2 * 0 0 0", "* * LW-1 * 0 0 0"} {
+		if _, err := DefaultParser.Parse(spec); !errors.Is(err, ErrInvalidExp) {
+			t.Errorf("expected %q is invalid, got %v", spec, err)
+		}
+	}
+}
+
+func TestNthWeekday(t *testing.T) {
+	tests := []struct {
+		spec     string
+		start    string
+		expected []string
+	}{
+		// Third Friday.
+		{"* * * 5#3 0 0 0", "2024-01-01T00:00:00+08:00", []string{
+			"2024-01-19T00:00:00+08:00",
+			"2024-02-16T00:00:00+08:00",
+			"2024-03-15T00:00:00+08:00",
+		}},
+		// Fifth Monday, months without one are skipped.
+		{"* * * 1#5 0 0 0", "2024-01-01T00:00:00+08:00", []string{
+			"2024-01-29T00:00:00+08:00",
+			"2024-04-29T00:00:00+08:00",
+			"2024-07-29T00:00:00+08:00",
+			"2024-09-30T00:00:00+08:00",
+		}},
+		// Second Tuesday and first Sunday.
+		{"* * * 2#2,0#1 0 0 0", "2024-11-06T00:00:00+08:00", []string{
+			"2024-11-12T00:00:00+08:00",
+			"2024-12-01T00:00:00+08:00",
+			"2024-12-10T00:00:00+08:00",
+		}},
+	}
+
+	for _, test := range tests {
+		sched, err := DefaultParser.Parse(test.spec)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		next := parseTime(test.start)
+		for _, item := range test.expected {
+			actual := sched.Next(next)
+			if expected := parseTime(item); !actual.Equal(expected) {
+				t.Errorf("Fail evaluating %s on %s: (expected) %s != %s (actual)",
+					test.spec, next, expected, actual)
+			}
+			next = actual
+		}
+	}
+
+	for _, spec := range []string{"* * * 5#0 0 0 0", "* * * 5#6 0 0 0", "* * * 8#1 0 0 0", "* * * 1-5#2 0 0 0", "* * * 5# 0 0 0", "* * * #2 0 0 0"} {
+		if _, err := DefaultParser.Parse(spec); !errors.Is(err, ErrInvalidExp) {
+			t.Errorf("expected %q is invalid, got %v", spec, err)
+		}
+	}
+}
+
+func TestNames(t *testing.T) {
+	start := parseTime("2024-11-06T00:00:00+08:00")
+
+	tests := []struct {
+		spec     string
+		expected string
+	}{
+		{"* JAN,JUL * * 0 0 0", "2025-01-01T00:00:00+08:00"},
+		{"* * * MON-FRI 0 0 0", "2024-11-07T00:00:00+08:00"},
+		{"* * * sat,Sun 0 0 0", "2024-11-09T00:00:00+08:00"},
+		{"* 1-FEB * * 0 0 0", "2025-01-01T00:00:00+08:00"},
+		{"* * * 1-wed/2 0 0 0", "2024-11-11T00:00:00+08:00"},
+		{"* * * FRI#3 0 0 0", "2024-11-15T00:00:00+08:00"},
+	}
+
+	for _, test := range tests {
+		sched, err := DefaultParser.Parse(test.spec)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		actual := sched.Next(start)
+		if expected := parseTime(test.expected); !actual.Equal(expected) {
+			t.Errorf("Fail evaluating %s on %s: (expected) %s != %s (actual)",
+				test.spec, start, expected, actual)
+		}
+	}
+
+	for _, spec := range []string{"* JANUARY * * 0 0 0", "* * * MON-FUN 0 0 0", "* * MON * 0 0 0"} {
+		_, err := DefaultParser.Parse(spec)
+		if !errors.Is(err, ErrInvalidExp) {
+			t.Errorf("expected %q is invalid, got %v", spec, err)
+		}
+	}
+
+	if _, err := DefaultParser.Parse("* * * MON-FUN 0 0 0"); err == nil || !strings.Contains(err.Error(), "FUN") {
+		t.Errorf("expected error names the unknown token, got %v", err)
+	}
+}
+
+// Parse stepped ranges in every field, expect exactly the values of each step within the range are selected.
+func TestSteps(t *testing.T) {
+	tests := []struct {
+		field    LayoutField
+		exp      string
+		expected []int
+	}{
+		{Year, "2024-2030/3", []int{2024, 2027, 2030}},
+		{Year, "*/100", []int{1970, 2070}},
+		{Year, "2090/4", []int{2090, 2094}},
+		{Month, "*/3", []int{1, 4, 7, 10}},
+		{Month, "2-6/2", []int{2, 4, 6}},
+		{Month, "FEB-AUG/3", []int{2, 5, 8}},
+		{Month, "11/5", []int{11}},
+		{Dom, "*/10", []int{1, 11, 21, 31}},
+		{Dom, "10-20/4", []int{10, 14, 18}},
+		{Dom, "1-5/40", []int{1}},
+		{Dow, "*/2", []int{0, 2, 4, 6}},
+		{Dow, "1-7/3", []int{0, 1, 4}},
+		{Dow, "MON-FRI/2", []int{1, 3, 5}},
+		{Dow, "FRI-SUN", []int{0, 5, 6}},
+		{Dow, "SAT-SUN", []int{0, 6}},
+		{Dow, "SUN-SAT", []int{0, 1, 2, 3, 4, 5, 6}},
+		{Hour, "*/6", []int{0, 6, 12, 18}},
+		{Hour, "9-17/4", []int{9, 13, 17}},
+		{Minute, "0-30/5", []int{0, 5, 10, 15, 20, 25, 30}},
+		{Minute, "*/15", []int{0, 15, 30, 45}},
+		{Minute, "50/7", []int{50, 57}},
+		{Second, "0-30/5", []int{0, 5, 10, 15, 20, 25, 30}},
+		{Second, "*/20", []int{0, 20, 40}},
+		{Second, "10-15/100", []int{10}},
+		{Second, "1,30-40/5", []int{1, 30, 35, 40}},
+	}
+
+	for _, test := range tests {
+		fields := slices.Repeat([]string{"*"}, len(DefaultLayout))
+		fields[slices.Index(DefaultLayout, test.field)] = test.exp
+		spec := strings.Join(fields, " ")
+
+		sched, err := DefaultParser.Parse(spec)
+		if err != nil {
+			t.Errorf("%s: %v", spec, err)
+			continue
+		}
+		st := sched.(*SchedTime)
+
+		var actual [2]uint64
+		switch test.field {
+		case Year:
+			actual = st.Year
+		case Month:
+			actual[0] = st.Month
+		case Dom:
+			actual[0] = st.Dom
+		case Dow:
+			actual[0] = st.Dow
+		case Hour:
+			actual[0] = st.Hour
+		case Minute:
+			actual[0] = st.Minute
+		case Second:
+			actual[0] = st.Second
+		}
+
+		var expected [2]uint64
+		for _, v := range test.expected {
+			if test.field == Year {
+				v -= 1970
+			}
+			expected[v/64] |= 1 << (v % 64)
+		}
+
+		if actual != expected {
+			t.Errorf("%s: expected %v selects %v, got %b", spec, test.field, test.expected, actual)
+		}
+	}
+}
+
+// Compute run times of stepped ranges, expect the selected values are enumerated in order.
+func TestStepsNext(t *testing.T) {
+	tests := []struct {
+		spec     string
+		start    string
+		expected []string
+	}{
+		{"* * * * * 0-30/10 0", "2024-11-06T10:20:30+08:00", []string{
+			"2024-11-06T10:30:00+08:00",
+			"2024-11-06T11:00:00+08:00",
+			"2024-11-06T11:10:00+08:00",
+		}},
+		{"* */5 1 * 0 0 0", "2024-11-06T10:20:30+08:00", []string{
+			"2025-01-01T00:00:00+08:00",
+			"2025-06-01T00:00:00+08:00",
+			"2025-11-01T00:00:00+08:00",
+		}},
+		{"* * * 1-5/2 12 0 0", "2024-11-06T10:20:30+08:00", []string{
+			"2024-11-06T12:00:00+08:00",
+			"2024-11-08T12:00:00+08:00",
+			"2024-11-11T12:00:00+08:00",
+		}},
+		{"* * * * * * 50/20", "2024-11-06T10:20:30+08:00", []string{
+			"2024-11-06T10:20:50+08:00",
+			"2024-11-06T10:21:50+08:00",
+		}},
+	}
+
+	for _, test := range tests {
+		sched, err := DefaultParser.Parse(test.spec)
+		if err != nil {
+			t.Errorf("%s: %v", test.spec, err)
+			continue
+		}
+
+		next := parseTime(test.start)
+		for _, item := range test.expected {
+			actual := sched.Next(next)
+			if expected := parseTime(item); !actual.Equal(expected) {
+				t.Errorf("Fail evaluating %s on %s: (expected) %s != %s (actual)", test.spec, next, expected, actual)
+			}
+			next = actual
+		}
+	}
+}
+
+func TestSundayAsSeven(t *testing.T) {
+	start := parseTime("2024-11-06T00:00:00+08:00")
+
+	sunday, err := DefaultParser.Parse("* * * 0 0 0 0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, spec := range []string{"* * * 7 0 0 0", "* * * SUN 0 0 0", "* * * 0,7 0 0 0"} {
+		sched, err := DefaultParser.Parse(spec)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if sched.(*SchedTime).Dow != sunday.(*SchedTime).Dow {
+			t.Errorf("expected %q selects sunday only, got %b", spec, sched.(*SchedTime).Dow)
+		}
+	}
+
+	// Second Sunday.
+	sched, err := DefaultParser.Parse("* * * 7#2 0 0 0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual, expected := sched.Next(start), parseTime("2024-11-10T00:00:00+08:00"); !actual.Equal(expected) {
+		t.Errorf("Fail evaluating 7#2: (expected) %s != %s (actual)", expected, actual)
+	}
+
+	// Friday through Sunday.
+	sched, err = DefaultParser.Parse("* * * 5-7 0 0 0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectSet := []string{
+		"2024-11-08T00:00:00+08:00",
+		"2024-11-09T00:00:00+08:00",
+		"2024-11-10T00:00:00+08:00",
+		"2024-11-15T00:00:00+08:00",
+	}
+	next := start
+	for _, item := range expectSet {
+		actual := sched.Next(next)
+		if expected := parseTime(item); !actual.Equal(expected) {
+			t.Errorf("Fail evaluating 5-7 on %s: (expected) %s != %s (actual)", next, expected, actual)
+		}
+		next = actual
+	}
+
+	if _, err := DefaultParser.Parse("* * * 8 0 0 0"); !errors.Is(err, ErrInvalidExp) {
+		t.Errorf("expected 8 is invalid, got %v", err)
+	}
+}
+
+func TestParserCache(t *testing.T) {
+	p := NewParser(WithCacheSize(2))
+
+	a1, _ := p.Parse("* * * * * * 0")
+	a2, _ := p.Parse("* * * * * * 0")
+	if a1 == a2 || *a1.(*SchedTime) != *a2.(*SchedTime) {
+		t.Error("expected identical expressions return copies of the cached schedule")
+	}
+
+	// Modifying a returned schedule must not affect the cache.
+	a2.(*SchedTime).Hour = 1
+	if a3, _ := p.Parse("* * * * * * 0"); *a3.(*SchedTime) != *a1.(*SchedTime) {
+		t.Error("expected cached schedule is not modified")
+	}
+
+	if _, err := p.Parse("invalid"); err == nil {
+		t.Error("expected error for invalid expression")
+	}
+	if n := p.cache.len(); n != 1 {
+		t.Errorf("expected invalid expression is not cached, got %d items", n)
+	}
+
+	// Using "* * * * * * 0" again leaves "* * * * * 0 0" as the least recently used one.
+	p.Parse("* * * * * 0 0")
+	p.Parse("* * * * * * 0")
+	p.Parse("* * * * 0 0 0")
+	if n := p.cache.len(); n != 2 {
+		t.Errorf("expected 2 cached items, got %d", n)
+	}
+	if _, ok := p.cache.get("* * * * * 0 0"); ok {
+		t.Error("expected least recently used schedule is evicted")
+	}
+
+	p = NewParser(WithCacheSize(0))
+	c1, _ := p.Parse("* * * * * * 0")
+	c2, _ := p.Parse("* * * * * * 0")
+	if c1 == c2 || p.cache != nil {
+		t.Error("expected no cache")
+	}
+}
+
+func TestSearchHorizon(t *testing.T) {
+	start := parseTime("2024-03-01T00:00:00+08:00")
+
+	tests := []struct {
+		spec     string
+		expected string
+	}{
+		// A specific year in the future.
+		{"2030 1 1 * 0 0 0", "2030-01-01T00:00:00+08:00"},
+		{"2024-2097/10 1 1 * 0 0 0", "2034-01-01T00:00:00+08:00"},
+		// February 29th on a Monday.
+		{"* 2 29 1 0 0 0", "2044-02-29T00:00:00+08:00"},
+		// Beyond the search horizon.
+		{"2090 1 1 * 0 0 0", ""},
+		// In the past.
+		{"2020 * * * * * *", ""},
+	}
+
+	for _, test := range tests {
+		sched, err := DefaultParser.Parse(test.spec)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		actual := sched.Next(start)
+		if expected := parseTime(test.expected); !actual.Equal(expected) {
+			t.Errorf("Fail evaluating %s on %s: (expected) %s != %s (actual)",
+				test.spec, start, expected, actual)
+		}
+	}
+
+	for _, spec := range []string{"* 2 30 * 0 0 0", "* 2 30,31 * 0 0 0", "* 4,6,9,11 31 * 0 0 0", "* 2 30W * 0 0 0", "* 2 L-29 * 0 0 0"} {
+		_, err := DefaultParser.Parse(spec)
+		if !errors.Is(err, ErrUnschedulable) || !errors.Is(err, ErrInvalidExp) {
+			t.Errorf("expected %q is unschedulable, got %v", spec, err)
+		}
+	}
+
+	for _, spec := range []string{"* 2 29 * 0 0 0", "* 2,3 30 * 0 0 0", "* 2 LW * 0 0 0", "* 2 L-28 * 0 0 0"} {
+		if _, err := DefaultParser.Parse(spec); err != nil {
+			t.Errorf("expected %q is valid, got %v", spec, err)
+		}
+	}
+}
+
+// Parse with the exported default parser, expect the same schedule as a new parser with the default layout.
+func TestDefaultParser(t *testing.T) {
+	start := parseTime("2024-11-06T10:20:30+08:00")
+
+	expected, err := NewParser(WithLayout(DefaultLayout)).Parse("* * * * 9 0 0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	actual, err := DefaultParser.Parse("* * * * 9 0 0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if a, e := actual.Next(start), expected.Next(start); !a.Equal(e) {
+		t.Errorf("expected %s, got %s", e, a)
+	}
+	if New().parser != DefaultParser {
+		t.Error("expected beat uses the default parser")
+	}
+}
@@ -0,0 +1,81 @@
+package beat
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+)
+
+// 持久化的任务信息
+type jobRecord struct {
+	Id   string     `json:"id"`
+	Expr string     `json:"expr"`
+	Prev *time.Time `json:"prev,omitempty"` // 从未运行时为 nil，不写入 JSON
+}
+
+// 获取所有任务的持久化信息，按任务ID排序
+func (b *Beat) records() []jobRecord {
+	entries := b.Entries()
+	records := make([]jobRecord, 0, len(entries))
+
+	for _, entry := range entries {
+		// 通过 AddSchedule 添加的任务没有定时表达式，无法恢复
+		if entry.Expr == "" {
+			continue
+		}
+		record := jobRecord{Id: entry.Id, Expr: entry.Expr}
+		if !entry.Prev.IsZero() {
+			record.Prev = &entry.Prev
+		}
+		records = append(records, record)
+	}
+
+	slices.SortFunc(records, func(a, b jobRecord) int {
+		return strings.Compare(a.Id, b.Id)
+	})
+
+	return records
+}
+
+// 将所有任务的ID、定时表达式和上一次运行时间序列化为 JSON
+func (b *Beat) MarshalJSON() ([]byte, error) {
+	return json.Marshal(b.records())
+}
+
+// 将所有任务的ID、定时表达式和上一次运行时间以 JSON 格式写入 w
+//
+// 任务回调和用户数据无法序列化，不会保存；通过 AddSchedule 添加的任务也不会保存
+func (b *Beat) Save(w io.Writer) error {
+	return json.NewEncoder(w).Encode(b.records())
+}
+
+// 从 r 中读取 Save 保存的任务并添加
+//
+// resolver 根据任务ID返回任务回调，返回 nil 时加载失败。
+// 任务通过 AddBatch 添加，所有表达式和任务回调均有效且全部任务都能添加时才会添加任务。
+// 上一次运行时间通过 WithLastRun 恢复，配合 WithCatchUp 可补执行停止期间错过的运行
+func (b *Beat) Load(r io.Reader, resolver func(id string) JobFunc) error {
+	var records []jobRecord
+	if err := json.NewDecoder(r).Decode(&records); err != nil {
+		return err
+	}
+
+	specs := make([]JobSpec, 0, len(records))
+	for _, record := range records {
+		fn := resolver(record.Id)
+		if fn == nil {
+			return fmt.Errorf("job %s: no function resolved", record.Id)
+		}
+
+		spec := JobSpec{Expr: record.Expr, Id: record.Id, Func: fn}
+		if record.Prev != nil {
+			spec.Options = []JobOption{WithLastRun(*record.Prev)}
+		}
+		specs = append(specs, spec)
+	}
+
+	return b.AddBatch(specs)
+}
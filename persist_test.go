@@ -0,0 +1,137 @@
+package beat
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+// Save jobs and load them into another beat, expect the same ids and expressions.
+func TestSaveAndLoad(t *testing.T) {
+	src := New()
+	src.Add("* * * * * * */5", "TestSaveAndLoad-2", nil, nil)
+	src.Add("@every 1m", "TestSaveAndLoad-1", nil, nil)
+
+	buf := &bytes.Buffer{}
+	if err := src.Save(buf); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := json.Marshal(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(bytes.TrimSpace(buf.Bytes()), data) {
+		t.Errorf("expected Save and MarshalJSON output match: %s != %s", buf.Bytes(), data)
+	}
+
+	resolved := []string{}
+	dst := New()
+	err = dst.Load(bytes.NewReader(data), func(id string) JobFunc {
+		resolved = append(resolved, id)
+		return func(ctx context.Context, userdata any) {}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(resolved) != 2 || resolved[0] != "TestSaveAndLoad-1" || resolved[1] != "TestSaveAndLoad-2" {
+		t.Errorf("unexpected resolved ids: %v", resolved)
+	}
+	for _, id := range resolved {
+		expected, _ := src.Entry(id)
+		actual, ok := dst.Entry(id)
+		if !ok || actual.Expr != expected.Expr {
+			t.Errorf("expected job %s with expr %q, got %+v", id, expected.Expr, actual)
+		}
+	}
+}
+
+// Load jobs with an invalid expression or unresolved function, expect nothing is added.
+func TestLoadInvalid(t *testing.T) {
+	dst := New()
+	resolver := func(id string) JobFunc {
+		if id == "unknown" {
+			return nil
+		}
+		return func(ctx context.Context, userdata any) {}
+	}
+
+	data := `[{"id":"a","expr":"@every 1s"},{"id":"b","expr":"invalid"}]`
+	if err := dst.Load(bytes.NewBufferString(data), resolver); !errors.Is(err, ErrInvalidExp) {
+		t.Errorf("expected ErrInvalidExp, got %v", err)
+	}
+
+	data = `[{"id":"a","expr":"@every 1s"},{"id":"unknown","expr":"@every 1s"}]`
+	if err := dst.Load(bytes.NewBufferString(data), resolver); err == nil {
+		t.Error("expected error for unresolved function")
+	}
+
+	if n := dst.Count(); n != 0 {
+		t.Errorf("expected no job is added, got %d", n)
+	}
+}
+
+// Load jobs with a duplicate id while duplicates are rejected, expect nothing is added.
+func TestLoadAtomic(t *testing.T) {
+	dst := New(WithRejectDuplicates())
+	dst.Add("@every 1s", "b", nil, nil)
+	resolver := func(id string) JobFunc {
+		return func(ctx context.Context, userdata any) {}
+	}
+
+	data := `[{"id":"a","expr":"@every 1s"},{"id":"b","expr":"@every 1s"}]`
+	if err := dst.Load(bytes.NewBufferString(data), resolver); !errors.Is(err, ErrJobExist) {
+		t.Errorf("expected %v, got %v", ErrJobExist, err)
+	}
+	if dst.Contains("a") {
+		t.Error("expected no job is added")
+	}
+}
+
+// Save a job with a last run time, expect it is restored on load.
+func TestSaveAndLoadLastRun(t *testing.T) {
+	lastRun := parseTime("2024-11-06T10:00:00+08:00")
+
+	src := New()
+	src.Add("@hourly", "TestSaveAndLoadLastRun-1", nil, nil, WithLastRun(lastRun))
+	src.Add("@hourly", "TestSaveAndLoadLastRun-2", nil, nil)
+
+	data, err := json.Marshal(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dst := New()
+	err = dst.Load(bytes.NewReader(data), func(id string) JobFunc {
+		return func(ctx context.Context, userdata any) {}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if entry, _ := dst.Entry("TestSaveAndLoadLastRun-1"); !entry.Prev.Equal(lastRun) {
+		t.Errorf("expected last run %v, got %v", lastRun, entry.Prev)
+	}
+	if entry, _ := dst.Entry("TestSaveAndLoadLastRun-2"); !entry.Prev.IsZero() {
+		t.Errorf("expected zero last run, got %v", entry.Prev)
+	}
+}
+
+// Save a job that has never run, expect no last run time in the JSON.
+func TestSaveNeverRun(t *testing.T) {
+	src := New()
+	src.Add("@hourly", "TestSaveNeverRun-1", nil, nil)
+
+	data, err := json.Marshal(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `[{"id":"TestSaveNeverRun-1","expr":"@hourly"}]`
+	if string(data) != expected {
+		t.Errorf("expected %s, got %s", expected, data)
+	}
+}
@@ -0,0 +1,110 @@
+package beat
+
+import (
+	"fmt"
+	"time"
+)
+
+// MinIntervalRule 检查的运行时间的数量
+const intervalSamples = 16
+
+// 表达式的校验规则，返回非 nil 错误时拒绝该表达式
+type Rule func(expr string, sched Schedule) error
+
+// 校验表达式的解析器
+type restrictedParser struct {
+	base  ScheduleParser
+	rules []Rule
+}
+
+// 创建校验表达式的解析器，使用 base 解析后依次检查所有规则
+//
+// 任意一个规则返回错误时，返回包装了 ErrRestricted 和规则错误的错误。可通过 WithParser 用于 Beat，
+// 使用户提交的表达式只能在允许的范围内
+func NewRestrictedParser(base ScheduleParser, rules ...Rule) ScheduleParser {
+	return &restrictedParser{base: base, rules: rules}
+}
+
+func (p *restrictedParser) Parse(expr string) (Schedule, error) {
+	sched, err := p.base.Parse(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, rule := range p.rules {
+		if err := rule(expr, sched); err != nil {
+			return nil, fmt.Errorf("%w: %s: %w", ErrRestricted, expr, err)
+		}
+	}
+
+	return sched, nil
+}
+
+// 创建限制最小间隔的规则，从现在开始的多次运行时间中任意两次的间隔小于 interval 时拒绝
+//
+// 只检查之后的 16 次运行时间，不运行或只运行一次的定时时间不受限制
+func MinIntervalRule(interval time.Duration) Rule {
+	return func(expr string, sched Schedule) error {
+		prev := sched.Next(time.Now())
+		if prev.IsZero() {
+			return nil
+		}
+
+		for range intervalSamples - 1 {
+			next := sched.Next(prev)
+			if next.IsZero() {
+				return nil
+			}
+			if gap := next.Sub(prev); gap < interval {
+				return fmt.Errorf("interval %s is less than %s", gap, interval)
+			}
+			prev = next
+		}
+
+		return nil
+	}
+}
+
+// 创建限制域取值范围的规则，域中含有 [min, max] 以外的值时拒绝
+//
+// 只检查解析为 *SchedTime 的表达式的数值，@every 等其他定时时间不受限制，可与 MinIntervalRule 组合使用；
+// 日域的 L、nW 和星期域的 d#n 不检查。星期域中的 7 在解析时视为 0
+func FieldRangeRule(field LayoutField, min, max int) Rule {
+	return func(expr string, sched Schedule) error {
+		st, ok := sched.(*SchedTime)
+		if !ok {
+			return nil
+		}
+
+		lower, upper := field.bounds()
+		for value := lower; value <= upper; value++ {
+			if (value < min || value > max) && st.hasValue(field, value) {
+				return fmt.Errorf("value %d is out of range [%d, %d]", value, min, max)
+			}
+		}
+
+		return nil
+	}
+}
+
+// 判断域中是否含有指定的值
+func (st *SchedTime) hasValue(field LayoutField, value int) bool {
+	switch field {
+	case Year:
+		return isYearMatch(st, value)
+	case Month:
+		return st.Month&(1<<value) != 0
+	case Dom:
+		return st.Dom&(1<<value) != 0
+	case Dow:
+		return st.Dow&(1<<value) != 0
+	case Hour:
+		return st.Hour&(1<<value) != 0
+	case Minute:
+		return st.Minute&(1<<value) != 0
+	case Second:
+		return st.Second&(1<<value) != 0
+	}
+
+	return false
+}
@@ -0,0 +1,49 @@
+package beat
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// Parse expressions with a restricted parser, expect disallowed ones are rejected with ErrRestricted.
+func TestRestrictedParser(t *testing.T) {
+	parser := NewRestrictedParser(DefaultParser,
+		MinIntervalRule(time.Minute),
+		FieldRangeRule(Hour, 8, 18),
+	)
+
+	tests := []struct {
+		expr    string
+		allowed bool
+	}{
+		{"* * * * 9 0 0", true},
+		{"* * * * 8-18 */5 0", true},
+		{"@every 1h", true},
+		{"* * * * 9 0 */10", false},
+		{"* * * * 9 * 0,1", false},
+		{"@every 30s", false},
+		{"* * * * 7 0 0", false},
+		{"* * * * * 0 0", false},
+		{"@daily", false},
+	}
+
+	for _, test := range tests {
+		_, err := parser.Parse(test.expr)
+		if test.allowed && err != nil {
+			t.Errorf("%s: expected allowed, got %v", test.expr, err)
+		}
+		if !test.allowed && !errors.Is(err, ErrRestricted) {
+			t.Errorf("%s: expected %v, got %v", test.expr, ErrRestricted, err)
+		}
+	}
+
+	if _, err := parser.Parse("invalid"); !errors.Is(err, ErrInvalidExp) || errors.Is(err, ErrRestricted) {
+		t.Errorf("expected parse error of the base parser, got %v", err)
+	}
+
+	beat := New(WithParser(parser))
+	if err := beat.Add("* * * * * * *", "TestRestrictedParser-1", nil, nil); !errors.Is(err, ErrRestricted) {
+		t.Errorf("expected %v, got %v", ErrRestricted, err)
+	}
+}
@@ -0,0 +1,191 @@
+package beat
+
+import (
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 固定间隔的定时，每次在给定时间的基础上增加固定的间隔
+type everySchedule struct {
+	delay time.Duration
+}
+
+// 创建固定间隔的定时，d 不大于 0 时任务不会运行
+func Every(d time.Duration) Schedule {
+	return everySchedule{delay: d}
+}
+
+// 获取下一个有效时间
+func (s everySchedule) Next(t time.Time) time.Time {
+	if s.delay <= 0 {
+		return time.Time{}
+	}
+
+	return t.Add(s.delay)
+}
+
+// 只运行一次的定时，在指定时间运行后不再运行
+type atSchedule struct {
+	at time.Time
+}
+
+// 创建只在 t 运行一次的定时，t 已经过去时任务不会运行
+func At(t time.Time) Schedule {
+	return atSchedule{at: t}
+}
+
+// 获取下一个有效时间，已经过了指定时间则返回零值时间
+func (s atSchedule) Next(t time.Time) time.Time {
+	if s.at.After(t) {
+		return s.at
+	}
+
+	return time.Time{}
+}
+
+// 只运行一次，是有限的定时
+func (atSchedule) Finite() bool {
+	return true
+}
+
+// 每个周期运行一次的定时，运行时间为周期内的随机时间
+type randomSchedule struct {
+	period time.Duration
+	lock   sync.Mutex
+	rand   *rand.Rand // 为 nil 时使用全局随机数
+}
+
+// 创建每个周期运行一次的定时，运行时间为周期内的随机时间，用于分散相同周期的任务
+//
+// 周期按 time.Time.Truncate 对齐，总是在给定时间的下一个周期中运行；period 不大于 0 时任务不会运行
+func RandomEach(period time.Duration) Schedule {
+	return &randomSchedule{period: period}
+}
+
+// 与 RandomEach 相同，使用 r 生成随机时间，便于使用固定的种子进行测试
+func RandomEachWithRand(period time.Duration, r *rand.Rand) Schedule {
+	return &randomSchedule{period: period, rand: r}
+}
+
+// 获取下一个有效时间，即下一个周期内的随机时间
+func (s *randomSchedule) Next(t time.Time) time.Time {
+	if s.period <= 0 {
+		return time.Time{}
+	}
+
+	var offset time.Duration
+	if s.rand != nil {
+		// rand.Rand 不是并发安全的
+		s.lock.Lock()
+		offset = time.Duration(s.rand.Int64N(int64(s.period)))
+		s.lock.Unlock()
+	} else {
+		offset = rand.N(s.period)
+	}
+
+	return t.Truncate(s.period).Add(s.period + offset)
+}
+
+// 多个定时的组合，任意一个定时到达时运行
+type unionSchedule []Schedule
+
+// 创建多个定时的组合，运行时间为各个定时的并集，同一时间只运行一次
+func Union(scheds ...Schedule) Schedule {
+	return unionSchedule(scheds)
+}
+
+// 获取下一个有效时间，即各个定时中最早的下一个有效时间
+func (s unionSchedule) Next(t time.Time) time.Time {
+	var next time.Time
+
+	for _, sched := range s {
+		n := sched.Next(t)
+		if !n.IsZero() && (next.IsZero() || n.Before(next)) {
+			next = n
+		}
+	}
+
+	return next
+}
+
+// 只在时间范围内运行的定时
+type betweenSchedule struct {
+	start time.Time
+	end   time.Time
+	inner Schedule
+}
+
+// 创建只在 [start, end) 内运行的定时，运行时间由 inner 决定
+//
+// start 为零值时间时不限制开始时间，end 为零值时间时不限制结束时间；过了 end 之后不再运行
+func Between(start, end time.Time, inner Schedule) Schedule {
+	return betweenSchedule{start: start, end: end, inner: inner}
+}
+
+// 获取下一个有效时间，范围外的时间被跳过，过了结束时间则返回零值时间
+func (s betweenSchedule) Next(t time.Time) time.Time {
+	if !s.end.IsZero() && !t.Before(s.end) {
+		return time.Time{}
+	}
+
+	// Next 返回晚于给定时间的时间，从开始时间的前一刻开始才能包括开始时间
+	if !s.start.IsZero() && t.Before(s.start) {
+		t = s.start.Add(-time.Nanosecond)
+	}
+
+	next := s.inner.Next(t)
+	if next.IsZero() || (!s.end.IsZero() && !next.Before(s.end)) {
+		return time.Time{}
+	}
+
+	return next
+}
+
+// 指定了结束时间时是有限的定时，否则与 inner 相同
+func (s betweenSchedule) Finite() bool {
+	if !s.end.IsZero() {
+		return true
+	}
+
+	if sched, ok := s.inner.(FiniteSchedule); ok {
+		return sched.Finite()
+	}
+
+	return true
+}
+
+// 最多运行 n 次的定时
+type timesSchedule struct {
+	n     int
+	inner Schedule
+	fires atomic.Int64 // 已经到达的运行次数，由调度器通过 fired 累加
+}
+
+// 创建最多运行 n 次的定时，运行时间由 inner 决定，运行 n 次后不再运行
+//
+// 次数按调度器实际到达的运行时间统计，包括补执行的运行和被 WithGuard 等跳过执行的运行，
+// 调用 Next 本身不计数，因此暂停、恢复和检查最小间隔等不会消耗运行次数。同一个定时不应用于多个任务。n 不大于 0 时不运行
+func Times(n int, inner Schedule) Schedule {
+	return &timesSchedule{n: n, inner: inner}
+}
+
+// 获取下一个有效时间，已经运行了 n 次则返回零值时间
+func (s *timesSchedule) Next(t time.Time) time.Time {
+	if s.fires.Load() >= int64(s.n) {
+		return time.Time{}
+	}
+
+	return s.inner.Next(t)
+}
+
+// 记录一次运行
+func (s *timesSchedule) fired(time.Time) {
+	s.fires.Add(1)
+}
+
+// 只运行有限次
+func (s *timesSchedule) Finite() bool {
+	return true
+}
@@ -0,0 +1,279 @@
+package beat
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"testing"
+	"time"
+)
+
+// Compute random times, expect each falls within the following period and seeded ones repeat.
+func TestRandomEach(t *testing.T) {
+	start := parseTime("2024-11-06T10:20:30+08:00")
+
+	next := start
+	sched := RandomEach(time.Hour)
+	for i := 1; i <= 100; i++ {
+		actual := sched.Next(next)
+		periodStart := start.Truncate(time.Hour).Add(time.Duration(i) * time.Hour)
+		if actual.Before(periodStart) || !actual.Before(periodStart.Add(time.Hour)) {
+			t.Fatalf("expected %s within the period starting at %s", actual, periodStart)
+		}
+		next = actual
+	}
+
+	a := RandomEachWithRand(time.Hour, rand.New(rand.NewPCG(1, 2)))
+	b := RandomEachWithRand(time.Hour, rand.New(rand.NewPCG(1, 2)))
+	for range 10 {
+		if x, y := a.Next(start), b.Next(start); !x.Equal(y) {
+			t.Fatalf("expected the same seed yields the same times: %s != %s", x, y)
+		}
+	}
+
+	if next := RandomEach(0).Next(start); !next.IsZero() {
+		t.Errorf("expected non-positive period never fires, got %s", next)
+	}
+}
+
+// Combine schedules, expect the earliest next time and a single run at shared instants.
+func TestUnion(t *testing.T) {
+	start := parseTime("2024-11-08T10:00:00+08:00") // Friday
+
+	weekdays, _ := DefaultParser.Parse("* * * 1-5 9 0 0")
+	sunday, _ := DefaultParser.Parse("* * * 0 12 0 0")
+	daily, _ := DefaultParser.Parse("* * * * 9 0 0")
+	sched := Union(weekdays, sunday, daily, At(time.Time{}))
+
+	expected := []string{
+		"2024-11-09T09:00:00+08:00",
+		"2024-11-10T09:00:00+08:00",
+		"2024-11-10T12:00:00+08:00",
+		"2024-11-11T09:00:00+08:00",
+	}
+
+	next := start
+	for _, item := range expected {
+		actual := sched.Next(next)
+		if expected := parseTime(item); !actual.Equal(expected) {
+			t.Errorf("(expected) %s != %s (actual)", expected, actual)
+		}
+		next = actual
+	}
+
+	if next := Union().Next(start); !next.IsZero() {
+		t.Errorf("expected empty union never fires, got %s", next)
+	}
+}
+
+// Bound a daily schedule to a date range, expect times outside the range are skipped and it ends after the range.
+func TestBetween(t *testing.T) {
+	daily, _ := DefaultParser.Parse("* * * * 9 0 0")
+	start := parseTime("2024-12-01T00:00:00+08:00")
+	end := parseTime("2024-12-03T09:00:00+08:00")
+	sched := Between(start, end, daily)
+
+	tests := []struct {
+		from     string
+		expected string
+	}{
+		{"2024-11-06T10:20:30+08:00", "2024-12-01T09:00:00+08:00"},
+		{"2024-12-01T09:00:00+08:00", "2024-12-02T09:00:00+08:00"},
+		{"2024-12-02T09:00:00+08:00", ""},
+		{"2024-12-05T10:00:00+08:00", ""},
+	}
+	for _, test := range tests {
+		actual := sched.Next(parseTime(test.from))
+		if test.expected == "" {
+			if !actual.IsZero() {
+				t.Errorf("from %s: expected no more run, got %s", test.from, actual)
+			}
+			continue
+		}
+		if expected := parseTime(test.expected); !actual.Equal(expected) {
+			t.Errorf("from %s: expected %s, got %s", test.from, expected, actual)
+		}
+	}
+
+	// The start instant itself is included.
+	exact := Between(parseTime("2024-12-01T09:00:00+08:00"), time.Time{}, daily)
+	if next := exact.Next(parseTime("2024-11-06T10:20:30+08:00")); !next.Equal(parseTime("2024-12-01T09:00:00+08:00")) {
+		t.Errorf("expected the start instant, got %s", next)
+	}
+
+	if !sched.(FiniteSchedule).Finite() {
+		t.Error("expected a schedule with an end is finite")
+	}
+	if Between(start, time.Time{}, afterSchedule{}).(FiniteSchedule).Finite() {
+		t.Error("expected finiteness follows the inner schedule without an end")
+	}
+}
+
+// Limit a schedule to three runs, expect repeated queries do not count and it ends after the third run.
+func TestTimes(t *testing.T) {
+	start := parseTime("2024-11-06T10:20:30+08:00")
+	sched := Times(3, Every(time.Minute))
+
+	// Queries without a run do not count.
+	for range 5 {
+		if next := sched.Next(start); !next.Equal(start.Add(time.Minute)) {
+			t.Fatalf("expected %s, got %s", start.Add(time.Minute), next)
+		}
+	}
+
+	next := start
+	for i := 1; i <= 3; i++ {
+		next = sched.Next(next)
+		if expected := start.Add(time.Duration(i) * time.Minute); !next.Equal(expected) {
+			t.Fatalf("run %d: expected %s, got %s", i, expected, next)
+		}
+		sched.(firedSchedule).fired(next)
+	}
+	if next := sched.Next(next); !next.IsZero() {
+		t.Errorf("expected no more run after 3 times, got %s", next)
+	}
+
+	if next := Times(0, Every(time.Minute)).Next(start); !next.IsZero() {
+		t.Errorf("expected non-positive count never fires, got %s", next)
+	}
+}
+
+// Pause and resume a count-limited job with a minimum interval, expect it still fires the given times and is then pruned.
+func TestTimesSchedule(t *testing.T) {
+	start := parseTime("2024-11-06T10:20:30+08:00")
+	clock := newFakeClock(start)
+	fired := make(chan time.Time, 4)
+	id := "TestTimesSchedule-1"
+
+	beat := New(WithClock(clock), WithLocation(start.Location()), WithAutoPrune(), WithMinInterval(time.Second))
+	if err := beat.AddSchedule(Times(3, Every(time.Minute)), id,
+		func(ctx context.Context, userdata any) { fired <- clock.Now() },
+		nil); err != nil {
+		t.Fatal(err)
+	}
+	beat.Start()
+	defer beat.Stop()
+
+	clock.BlockUntil(1)
+	clock.Advance(30 * time.Second)
+	beat.Sync()
+
+	if err := beat.Pause(id); err != nil {
+		t.Fatal(err)
+	}
+	clock.BlockUntil(1)
+	clock.Advance(10 * time.Second)
+	beat.Sync()
+	if err := beat.Resume(id); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 1; i <= 3; i++ {
+		clock.BlockUntil(1)
+		clock.Advance(time.Minute)
+		beat.Sync()
+
+		select {
+		case at := <-fired:
+			if expected := start.Add(40*time.Second + time.Duration(i)*time.Minute); !at.Equal(expected) {
+				t.Errorf("run %d: expected %s, got %s", i, expected, at)
+			}
+		case <-time.After(OneSecond):
+			t.Fatalf("expected run %d", i)
+		}
+	}
+
+	select {
+	case <-fired:
+		t.Error("expected no more run after 3 times")
+	case <-time.After(10 * time.Millisecond):
+	}
+	if beat.Contains(id) {
+		t.Error("expected finished job is pruned")
+	}
+}
+
+// Add a job with a fixed interval schedule, expect it fires every interval.
+func TestAddSchedule(t *testing.T) {
+	start := parseTime("2024-11-06T10:20:30+08:00")
+	clock := newFakeClock(start)
+	fired := make(chan time.Time, 1)
+
+	beat := New(WithClock(clock), WithLocation(start.Location()))
+	if err := beat.AddSchedule(Every(0), "", nil, nil); !errors.Is(err, ErrEmptyId) {
+		t.Errorf("expected ErrEmptyId, got %v", err)
+	}
+	if err := beat.AddSchedule(Every(90*time.Second), "TestAddSchedule-1",
+		func(ctx context.Context, userdata any) { fired <- clock.Now() },
+		nil); err != nil {
+		t.Fatal(err)
+	}
+	beat.Start()
+	defer beat.Stop()
+
+	for i := 1; i <= 3; i++ {
+		clock.BlockUntil(1)
+		clock.Advance(90 * time.Second)
+
+		select {
+		case tm := <-fired:
+			if expected := start.Add(time.Duration(i) * 90 * time.Second); !tm.Equal(expected) {
+				t.Errorf("(expected) %s != %s (actual)", expected, tm)
+			}
+		case <-time.After(OneSecond):
+			t.Fatalf("expected job fires %d times", i)
+		}
+	}
+
+	if entry, _ := beat.Entry("TestAddSchedule-1"); entry.Expr != "" {
+		t.Errorf("expected empty expr, got %q", entry.Expr)
+	}
+	if data, _ := beat.MarshalJSON(); string(data) != "[]" {
+		t.Errorf("expected job without expression is not saved, got %s", data)
+	}
+	if next := Every(0).Next(start); !next.IsZero() {
+		t.Errorf("expected non-positive interval never fires, got %s", next)
+	}
+}
+
+// Add a one-shot job, expect it fires once at the given time and never again.
+func TestAt(t *testing.T) {
+	start := parseTime("2024-11-06T10:20:30+08:00")
+	clock := newFakeClock(start)
+	fired := make(chan time.Time, 2)
+
+	beat := New(WithClock(clock), WithLocation(start.Location()))
+	beat.AddSchedule(At(start.Add(2*time.Minute)), "TestAt-1",
+		func(ctx context.Context, userdata any) { fired <- clock.Now() },
+		nil)
+	beat.AddSchedule(At(start.Add(-time.Minute)), "TestAt-2",
+		func(ctx context.Context, userdata any) { fired <- clock.Now() },
+		nil)
+	beat.Start()
+	defer beat.Stop()
+
+	clock.BlockUntil(1)
+	clock.Advance(2 * time.Minute)
+
+	select {
+	case tm := <-fired:
+		if expected := start.Add(2 * time.Minute); !tm.Equal(expected) {
+			t.Errorf("(expected) %s != %s (actual)", expected, tm)
+		}
+	case <-time.After(OneSecond):
+		t.Fatal("expected job fires")
+	}
+
+	clock.BlockUntil(1)
+	clock.Advance(8760 * time.Hour)
+
+	select {
+	case tm := <-fired:
+		t.Fatalf("expected job fires only once, fired again at %s", tm)
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	if entry, _ := beat.Entry("TestAt-1"); !entry.Next.IsZero() {
+		t.Errorf("expected zero next time, got %s", entry.Next)
+	}
+}
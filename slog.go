@@ -0,0 +1,60 @@
+package beat
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+)
+
+// 将 Logger 的调用转换为 slog 的 logger
+type slogLogger struct {
+	log *slog.Logger
+}
+
+// 使用 slog.Logger 创建 Logger
+//
+// 键 "msg" 对应的值作为日志消息，其余键值对作为属性；error 类型的值保留为 error 属性
+func NewSlogLogger(log *slog.Logger) Logger {
+	return &slogLogger{log: log}
+}
+
+func (l *slogLogger) output(level slog.Level, keyvals ...any) {
+	if !l.log.Enabled(context.Background(), level) {
+		return
+	}
+
+	if len(keyvals)&1 == 1 {
+		keyvals = append(keyvals, "!UNPAIRED")
+	}
+
+	msg := ""
+	attrs := make([]slog.Attr, 0, len(keyvals)/2)
+
+	for i := 0; i < len(keyvals); i += 2 {
+		key := fmt.Sprint(keyvals[i])
+		if key == "msg" {
+			msg = fmt.Sprint(keyvals[i+1])
+			continue
+		}
+
+		attrs = append(attrs, slog.Any(key, keyvals[i+1]))
+	}
+
+	l.log.LogAttrs(context.Background(), level, msg, attrs...)
+}
+
+func (l *slogLogger) Debug(keyvals ...any) {
+	l.output(slog.LevelDebug, keyvals...)
+}
+
+func (l *slogLogger) Info(keyvals ...any) {
+	l.output(slog.LevelInfo, keyvals...)
+}
+
+func (l *slogLogger) Warn(keyvals ...any) {
+	l.output(slog.LevelWarn, keyvals...)
+}
+
+func (l *slogLogger) Error(keyvals ...any) {
+	l.output(slog.LevelError, keyvals...)
+}
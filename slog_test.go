@@ -0,0 +1,36 @@
+package beat
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"testing"
+)
+
+// Log through the slog adapter, expect message, attributes and level are preserved.
+func TestSlogLogger(t *testing.T) {
+	buf := &bytes.Buffer{}
+	log := NewSlogLogger(slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelInfo})))
+
+	log.Debug("msg", "hidden")
+	log.Error("msg", "failed", "job.id", "a", "error", errors.New("boom"), "odd")
+
+	var record map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("expected a single JSON record, got %q: %v", buf.String(), err)
+	}
+
+	expected := map[string]any{
+		"level":  "ERROR",
+		"msg":    "failed",
+		"job.id": "a",
+		"error":  "boom",
+		"odd":    "!UNPAIRED",
+	}
+	for key, value := range expected {
+		if record[key] != value {
+			t.Errorf("expected %s=%v, got %v", key, value, record[key])
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package beat
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// 任务 panic 时记录的错误
+var errJobPanicked = errors.New("job panicked")
+
+// 任务执行统计
+type JobStats struct {
+	Runs         uint64        // 执行次数
+	Successes    uint64        // 成功次数
+	Errors       uint64        // 失败次数，包括返回错误和 panic
+	LastRun      time.Time     // 最近一次开始执行的时间
+	LastDuration time.Duration // 最近一次执行的耗时
+	LastError    error         // 最近一次执行返回的错误，成功时为 nil
+}
+
+// 并发安全的任务执行统计，任务执行时更新，查询时读取
+type jobStats struct {
+	lock  sync.Mutex
+	stats JobStats
+}
+
+// 记录一次执行结果
+func (s *jobStats) record(start time.Time, d time.Duration, err error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.stats.Runs++
+	if err != nil {
+		s.stats.Errors++
+	} else {
+		s.stats.Successes++
+	}
+	s.stats.LastRun = start
+	s.stats.LastDuration = d
+	s.stats.LastError = err
+}
+
+// 清空统计
+func (s *jobStats) reset() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.stats = JobStats{}
+}
+
+// 获取统计的副本
+func (s *jobStats) snapshot() JobStats {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.stats
+}
+
+// 获取指定任务的执行统计
+//
+// 不存在则返回 nil
+func (b *Beat) jobStats(id string) *JobStats {
+	job := b.find(id)
+	if job == nil {
+		return nil
+	}
+
+	stats := job.stats.snapshot()
+	return &stats
+}
+
+// 获取任务的执行统计
+//
+// 任务不存在时第二个返回值为 false；重试的任务每次触发只计为一次执行
+func (b *Beat) Stats(id string) (JobStats, bool) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	var stats *JobStats
+	if !b.running {
+		stats = b.jobStats(id)
+	} else {
+		reply := make(chan *JobStats)
+		b.operate <- opStats{id: id, reply: reply}
+		stats = <-reply
+	}
+
+	if stats == nil {
+		return JobStats{}, false
+	}
+
+	return *stats, true
+}
@@ -0,0 +1,128 @@
+package beat
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// 默认的 TryAdd、TryRemove 等待时间
+const defaultTryTimeout = 100 * time.Millisecond
+
+// 在 deadline 前获取互斥锁，超时返回 false
+//
+// 锁被占用时由辅助协程等待加锁并移交给调用方；调用方超时后，辅助协程获得的锁将被立即释放
+func (b *Beat) tryLock(deadline time.Time) bool {
+	if b.lock.TryLock() {
+		return true
+	}
+
+	locked := make(chan struct{})
+	abandoned := make(chan struct{})
+	go func() {
+		b.lock.Lock()
+		select {
+		case locked <- struct{}{}:
+		case <-abandoned:
+			b.lock.Unlock()
+		}
+	}()
+
+	timer := time.NewTimer(time.Until(deadline))
+	defer timer.Stop()
+
+	select {
+	case <-locked:
+		return true
+	case <-timer.C:
+		close(abandoned)
+		return false
+	}
+}
+
+// 在 deadline 前将操作发送给调度协程，超时返回 false
+//
+// 调用前需持有互斥锁
+func (b *Beat) trySend(op any, deadline time.Time) bool {
+	timer := time.NewTimer(time.Until(deadline))
+	defer timer.Stop()
+
+	select {
+	case b.operate <- op:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
+
+// 尝试添加任务，调度器繁忙时不阻塞
+//
+// 参数与 Add 相同。无法在 WithTryTimeout 设置的时间内提交，或提交后调度协程未能在该时间内开始处理时返回 ErrBusy，
+// 任务不会被添加，调用方可稍后重试
+func (b *Beat) TryAdd(expr string, id string, fn JobFunc, userdata any, opts ...JobOption) error {
+	if id == "" {
+		return ErrEmptyId
+	}
+
+	deadline := time.Now().Add(b.tryTimeout)
+
+	if !b.tryLock(deadline) {
+		return ErrBusy
+	}
+	defer b.lock.Unlock()
+
+	job, err := b.parseJob(expr, id, toJobFuncE(fn), userdata, opts)
+	if err != nil {
+		return err
+	}
+	if err := b.checkInterval(job.Schedule); err != nil {
+		return err
+	}
+
+	if !b.running {
+		return b.addJob(job)
+	}
+
+	// 通道有缓冲时请求可能仍在排队，超时后放弃尚未执行的请求
+	claim := new(atomic.Bool)
+	reply := make(chan error, 1)
+	if !b.trySend(opAdd{job: job, reply: reply, claim: claim}, deadline) {
+		return ErrBusy
+	}
+
+	timer := time.NewTimer(time.Until(deadline))
+	defer timer.Stop()
+
+	select {
+	case err := <-reply:
+		return err
+	case <-timer.C:
+		if claim.CompareAndSwap(false, true) {
+			return ErrBusy
+		}
+		// 调度协程已开始执行请求，很快会返回结果
+		return <-reply
+	}
+}
+
+// 尝试移除任务，调度器繁忙时不阻塞
+//
+// 无法在 WithTryTimeout 设置的时间内提交时返回 ErrBusy，任务不会被移除
+func (b *Beat) TryRemove(id string) error {
+	deadline := time.Now().Add(b.tryTimeout)
+
+	if !b.tryLock(deadline) {
+		return ErrBusy
+	}
+	defer b.lock.Unlock()
+
+	if !b.running {
+		b.removeJob(id)
+		return nil
+	}
+
+	if !b.trySend(opRemove(id), deadline) {
+		return ErrBusy
+	}
+
+	return nil
+}
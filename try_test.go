@@ -0,0 +1,158 @@
+package beat
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// Try to add and remove jobs while idle, expect they succeed.
+func TestTryAddAndRemove(t *testing.T) {
+	beat := New()
+
+	if err := beat.TryAdd("* * * * * * *", "", nil, nil); !errors.Is(err, ErrEmptyId) {
+		t.Errorf("expected %v, got %v", ErrEmptyId, err)
+	}
+	if err := beat.TryAdd("invalid", "TestTryAdd-1", nil, nil); !errors.Is(err, ErrInvalidExp) {
+		t.Errorf("expected %v, got %v", ErrInvalidExp, err)
+	}
+	if err := beat.TryAdd("* * * * * * *", "TestTryAdd-1", nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	beat.Start()
+	defer beat.Stop()
+
+	if err := beat.TryAdd("* * * * * * *", "TestTryAdd-2", nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !beat.Contains("TestTryAdd-2") {
+		t.Error("expected job is added")
+	}
+
+	if err := beat.TryRemove("TestTryAdd-1"); err != nil {
+		t.Fatal(err)
+	}
+	if beat.Contains("TestTryAdd-1") {
+		t.Error("expected job is removed")
+	}
+}
+
+// Block the scheduler in a guard, expect TryAdd and TryRemove return ErrBusy.
+func TestTryAddBusy(t *testing.T) {
+	entered := make(chan struct{})
+	release := make(chan struct{})
+
+	beat := New(WithTryTimeout(10 * time.Millisecond))
+	beat.Add("* * * * * * *", "TestTryAddBusy-1", nil, nil, WithGuard(func(context.Context, any) bool {
+		select {
+		case entered <- struct{}{}:
+			<-release
+		default:
+		}
+		return false
+	}))
+	beat.Start()
+	defer beat.Stop()
+
+	select {
+	case <-entered:
+	case <-time.After(2 * OneSecond):
+		t.Fatal("expected scheduler enters the guard")
+	}
+
+	if err := beat.TryAdd("* * * * * * *", "TestTryAddBusy-2", nil, nil); !errors.Is(err, ErrBusy) {
+		t.Errorf("expected %v, got %v", ErrBusy, err)
+	}
+	if err := beat.TryRemove("TestTryAddBusy-1"); !errors.Is(err, ErrBusy) {
+		t.Errorf("expected %v, got %v", ErrBusy, err)
+	}
+
+	close(release)
+
+	if beat.Contains("TestTryAddBusy-2") {
+		t.Error("expected job is not added")
+	}
+}
+
+// Block the scheduler with a buffered request channel, expect TryAdd gives up the queued request in time.
+func TestTryAddBusyBuffered(t *testing.T) {
+	entered := make(chan struct{})
+	release := make(chan struct{})
+
+	beat := New(WithTryTimeout(10*time.Millisecond), WithOperateBuffer(4))
+	beat.Add("* * * * * * *", "TestTryAddBusyBuffered-1", nil, nil, WithGuard(func(context.Context, any) bool {
+		select {
+		case entered <- struct{}{}:
+			<-release
+		default:
+		}
+		return false
+	}))
+	beat.Start()
+	defer beat.Stop()
+
+	select {
+	case <-entered:
+	case <-time.After(2 * OneSecond):
+		t.Fatal("expected scheduler enters the guard")
+	}
+
+	start := time.Now()
+	if err := beat.TryAdd("* * * * * * *", "TestTryAddBusyBuffered-2", nil, nil); !errors.Is(err, ErrBusy) {
+		t.Errorf("expected %v, got %v", ErrBusy, err)
+	}
+	if elapsed := time.Since(start); elapsed > OneSecond/2 {
+		t.Errorf("expected TryAdd returns within the timeout, took %v", elapsed)
+	}
+
+	close(release)
+	beat.Sync()
+
+	if beat.Contains("TestTryAddBusyBuffered-2") {
+		t.Error("expected abandoned job is not added")
+	}
+}
+
+// Hold the lock while adding a job with a minimum interval, expect TryAdd returns ErrBusy without blocking.
+func TestTryAddLocked(t *testing.T) {
+	beat := New(WithTryTimeout(10*time.Millisecond), WithMinInterval(time.Minute))
+
+	beat.lock.Lock()
+	done := make(chan error, 1)
+	go func() { done <- beat.TryAdd("* * * * * 0 0", "TestTryAddLocked-1", nil, nil) }()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, ErrBusy) {
+			t.Errorf("expected %v, got %v", ErrBusy, err)
+		}
+	case <-time.After(OneSecond):
+		t.Error("expected TryAdd does not block")
+	}
+	beat.lock.Unlock()
+
+	if err := beat.TryAdd("* * * * * * *", "TestTryAddLocked-2", nil, nil); !errors.Is(err, ErrTooFrequent) {
+		t.Errorf("expected %v, got %v", ErrTooFrequent, err)
+	}
+}
+
+// Release the lock before the try timeout, expect TryAdd waits for it and adds the job.
+func TestTryAddWaitsForLock(t *testing.T) {
+	beat := New(WithTryTimeout(OneSecond))
+
+	beat.lock.Lock()
+	done := make(chan error, 1)
+	go func() { done <- beat.TryAdd("* * * * * * *", "TestTryAddWaitsForLock-1", nil, nil) }()
+
+	time.Sleep(10 * time.Millisecond)
+	beat.lock.Unlock()
+
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if !beat.Contains("TestTryAddWaitsForLock-1") {
+		t.Error("expected job is added")
+	}
+}
@@ -0,0 +1,38 @@
+package beat
+
+import "context"
+
+// 用户数据类型化的 Beat，任务将直接收到对应类型的用户数据
+//
+// 调度由内嵌的 Beat 完成
+type TypedBeat[T any] struct {
+	*Beat
+}
+
+// 使用已有的 Beat 创建 TypedBeat
+func NewTyped[T any](b *Beat) *TypedBeat[T] {
+	return &TypedBeat[T]{Beat: b}
+}
+
+// 添加任务
+//
+// 参数与 Beat.Add 相同，data 将以类型 T 传递给任务
+func (tb *TypedBeat[T]) Add(expr string, id string, fn func(ctx context.Context, data T), data T, opts ...JobOption) error {
+	var fnE JobFuncE
+	if fn != nil {
+		fnE = func(ctx context.Context, userdata any) error {
+			data, _ := userdata.(T)
+			fn(ctx, data)
+			return nil
+		}
+	}
+
+	return tb.Beat.add(expr, id, fnE, data, opts)
+}
+
+// 更新任务的用户数据，下一次执行时生效
+//
+// 任务不存在时返回 ErrJobNotExist
+func (tb *TypedBeat[T]) SetUserdata(id string, data T) error {
+	return tb.Beat.SetUserdata(id, data)
+}
@@ -0,0 +1,49 @@
+package beat
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+type typedPayload struct {
+	name string
+}
+
+// Add a typed job, expect it receives the typed userdata.
+func TestTypedBeat(t *testing.T) {
+	id := "TestTypedBeat-1"
+	ch := make(chan *typedPayload, 1)
+
+	tb := NewTyped[*typedPayload](New())
+	tb.Add("* 1 1 * 0 0 0", id,
+		func(ctx context.Context, data *typedPayload) { ch <- data },
+		&typedPayload{name: "old"})
+
+	if err := tb.SetUserdata(id, &typedPayload{name: "new"}); err != nil {
+		t.Fatal(err)
+	}
+	tb.RunNow(id)
+
+	select {
+	case <-time.After(OneSecond):
+		t.Fatal("expected job runs")
+	case data := <-ch:
+		if data.name != "new" {
+			t.Errorf("expected new payload, got %s", data.name)
+		}
+	}
+
+	// A nil payload is delivered as the zero value.
+	tb.SetUserdata(id, nil)
+	tb.RunNow(id)
+
+	select {
+	case <-time.After(OneSecond):
+		t.Fatal("expected job runs")
+	case data := <-ch:
+		if data != nil {
+			t.Errorf("expected nil payload, got %v", data)
+		}
+	}
+}